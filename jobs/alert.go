@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// alertBatch created jobs of a tenant waiting on queue to be alerted
+type alertBatch struct {
+	tenant string
+	jobs   []Job
+}
+
+// jobAlerter matches new jobs against saved searches and sends an alert for each matched search to sink.
+// jobs are alerted by a worker draining a queue, so slow sinks do not hold ingestion workers
+type jobAlerter struct {
+	repository AlertRepository
+	sink       AlertSink
+	queue      chan alertBatch
+	retries    int
+	retryWait  time.Duration
+	wg         sync.WaitGroup
+}
+
+// newJobAlerter jobAlerter constructor, starts the worker. failed batches are retried up to retries times waiting retryWait, doubled on each retry
+func newJobAlerter(repository AlertRepository, sink AlertSink, queueSize, retries int, retryWait time.Duration) *jobAlerter {
+	if retries < 0 {
+		retries = 0
+	}
+	a := &jobAlerter{repository: repository, sink: sink, queue: make(chan alertBatch, queueSize), retries: retries, retryWait: retryWait}
+	a.wg.Add(1)
+	go a.worker()
+	return a
+}
+
+// enqueue adds jobs on queue without blocking, jobs are dropped when the queue is full
+func (a *jobAlerter) enqueue(ctx context.Context, jobs []Job) {
+	if len(jobs) == 0 {
+		return
+	}
+	select {
+	case a.queue <- alertBatch{tenant: TenantFromContext(ctx), jobs: jobs}:
+	default:
+		log.Printf("message=\"alert queue is full, dropping job alerts\" kind=alert tenant=%s size=%d", TenantFromContext(ctx), len(jobs))
+	}
+}
+
+// close stops receiving jobs and waits for the worker to alert the queue
+func (a *jobAlerter) close() {
+	close(a.queue)
+	a.wg.Wait()
+}
+
+func (a *jobAlerter) worker() {
+	defer a.wg.Done()
+	for batch := range a.queue {
+		a.deliver(ContextWithTenant(context.Background(), batch.tenant), batch.jobs)
+	}
+}
+
+// deliver alerts jobs retrying on errors, jobs are dropped after the last retry
+func (a *jobAlerter) deliver(ctx context.Context, jobs []Job) {
+	for attempt := 0; ; attempt++ {
+		err := a.alert(ctx, jobs)
+		if err == nil {
+			return
+		}
+		if attempt >= a.retries {
+			log.Printf("message=\"error sending job alerts, dropping them\" kind=alert tenant=%s size=%d attempts=%d error=\"%s\"", TenantFromContext(ctx), len(jobs), attempt+1, err.Error())
+			return
+		}
+		wait := a.retryWait << uint(attempt)
+		log.Printf("message=\"error sending job alerts, retry after %s\" kind=alert tenant=%s size=%d error=\"%s\"", wait, TenantFromContext(ctx), len(jobs), err.Error())
+		time.Sleep(wait)
+	}
+}
+
+// alert matches jobs and sends the alerts grouped by saved search, on the order searches were first matched
+func (a *jobAlerter) alert(ctx context.Context, jobs []Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	matches, err := a.repository.Match(ctx, jobs)
+	if err != nil {
+		return err
+	}
+
+	var alerts []Alert
+	positions := make(map[string]int)
+	for i, ids := range matches {
+		for _, id := range ids {
+			n, ok := positions[id]
+			if !ok {
+				n = len(alerts)
+				positions[id] = n
+				alerts = append(alerts, Alert{SearchID: id, Tenant: TenantFromContext(ctx)})
+			}
+			alerts[n].Jobs = append(alerts[n].Jobs, JobHit{ID: jobs[i].ID(), Job: jobs[i]})
+		}
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	return a.sink.Send(ctx, alerts)
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+)
+
+// AlertRepository registers saved searches as alerts and finds the alerts matching jobs
+type AlertRepository interface {
+	Register(ctx context.Context, search SavedSearch) error
+	Unregister(ctx context.Context, id string) error
+	Match(ctx context.Context, jobs []Job) ([][]string, error)
+	UpdateMapping(ctx context.Context, mapping string) error
+}
+
+// ElasticSearchAlertRepository AlertRepository impl for elastic search, saved searches are percolator queries on the 'alerts' index of each tenant with the jobs mapping
+type ElasticSearchAlertRepository struct {
+	repository  Repository
+	mapping     string
+	initialized map[string]bool
+	rmutex      sync.RWMutex
+}
+
+// newElasticSearchAlertRepository ElasticSearchAlertRepository constructor, mapping is the jobs index mapping
+func newElasticSearchAlertRepository(elasticSearch Repository, mapping string) AlertRepository {
+	return &ElasticSearchAlertRepository{repository: elasticSearch, mapping: mapping, initialized: make(map[string]bool)}
+}
+
+// index alerts index of the tenant on ctx, initialized on first use
+func (r *ElasticSearchAlertRepository) index(ctx context.Context) (string, error) {
+	index := tenantIndex(ctx, "alerts")
+	r.rmutex.RLock()
+	initialized := r.initialized[index]
+	r.rmutex.RUnlock()
+
+	if !initialized {
+		r.rmutex.Lock()
+		defer r.rmutex.Unlock()
+		if r.initialized[index] { //initialized by another request while waiting
+			return index, nil
+		}
+		if err := r.initIndex(ctx, index, r.mapping); err != nil {
+			return "", err
+		}
+		r.initialized[index] = true
+	}
+	return index, nil
+}
+
+func (r *ElasticSearchAlertRepository) initIndex(ctx context.Context, index, mapping string) error {
+	percolatorMapping, err := withPercolator(mapping)
+	if err != nil {
+		return NewInvalidRequestError(err.Error())
+	}
+	return r.repository.InitIndex(ctx, index, percolatorMapping)
+}
+
+// UpdateMapping replaces jobs mapping, migrating alerts of the tenant on ctx to a new index when mapping changed,
+// indexes of other tenants are migrated on their next use
+func (r *ElasticSearchAlertRepository) UpdateMapping(ctx context.Context, mapping string) error {
+	index := tenantIndex(ctx, "alerts")
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	if err := r.initIndex(ctx, index, mapping); err != nil {
+		return err
+	}
+	r.mapping = mapping
+	r.initialized = map[string]bool{index: true}
+	return nil
+}
+
+// Register stores saved search criteria as a percolator query with the saved search id
+func (r *ElasticSearchAlertRepository) Register(ctx context.Context, search SavedSearch) error {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return err
+	}
+	queries, err := createJobQueries(search.JobSearch())
+	if err != nil {
+		return err
+	}
+	return r.repository.AddPercolator(ctx, index, search.ID, queries...)
+}
+
+// Unregister removes the percolator query of saved search with id, searches without percolator query are ignored
+func (r *ElasticSearchAlertRepository) Unregister(ctx context.Context, id string) error {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return err
+	}
+	if err := r.repository.Delete(ctx, index, percolatorType, id); err != nil {
+		if jobErr, ok := err.(*JobError); ok && jobErr.Type() == ERROR_NOT_FOUND {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Match finds the ids of saved searches matching each job, on the same order the jobs were sent
+func (r *ElasticSearchAlertRepository) Match(ctx context.Context, jobs []Job) ([][]string, error) {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]Indexable, len(jobs))
+	for i, job := range jobs {
+		docs[i] = job
+	}
+	return r.repository.Percolate(ctx, index, docs)
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestElasticSearchAlertRepository_Register(t *testing.T) {
+	tests := []struct {
+		name        string
+		search      SavedSearch
+		err         error
+		wantQueries []Query
+		wantErr     bool
+	}{
+		{"invalid query", SavedSearch{ID: "s1", Query: "title:(java"}, nil, nil, true},
+		{"error", SavedSearch{ID: "s1", Content: "java"}, errors.New("error"), []Query{{Value: "java", Fields: []string{"title^3", "description"}, Operator: "and"}}, true},
+		{"success", SavedSearch{ID: "s1", Query: "title:java", State: "sc"}, nil, []Query{{Value: "java", Fields: []string{"title"}, Operator: "and", Match: MatchWords}, {Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotQueries []Query
+			r := newElasticSearchAlertRepository(&mockRepository{initFn: func() error { return nil }, addPercolatorFn: func(id string, queries []Query) error {
+				if id != tt.search.ID {
+					t.Errorf("ElasticSearchAlertRepository.Register() id = %v, want %v", id, tt.search.ID)
+				}
+				gotQueries = queries
+				return tt.err
+			}}, `{"mappings":{}}`)
+			if err := r.Register(context.TODO(), tt.search); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchAlertRepository.Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotQueries, tt.wantQueries) {
+				t.Errorf("ElasticSearchAlertRepository.Register() queries = %v, want %v", gotQueries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestElasticSearchAlertRepository_Unregister(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"not found is ignored", NewNotFoundError("id s1 not found"), false},
+		{"error", NewElasticsearchAccessError("error"), true},
+		{"success", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newElasticSearchAlertRepository(&mockRepository{initFn: func() error { return nil }, deleteFn: func() error { return tt.err }}, `{"mappings":{}}`)
+			if err := r.Unregister(context.TODO(), "s1"); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchAlertRepository.Unregister() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElasticSearchAlertRepository_Match(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+		want    [][]string
+		wantErr bool
+	}{
+		{"invalid mapping", "{", nil, true},
+		{"success", `{"mappings":{}}`, [][]string{{"s1"}, nil}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotDocs []Indexable
+			r := newElasticSearchAlertRepository(&mockRepository{initFn: func() error { return nil }, percolateFn: func(docs []Indexable) ([][]string, error) {
+				gotDocs = docs
+				return [][]string{{"s1"}, nil}, nil
+			}}, tt.mapping)
+			jobs := []Job{{Title: "a"}, {Title: "b"}}
+			got, err := r.Match(context.TODO(), jobs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchAlertRepository.Match() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearchAlertRepository.Match() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(gotDocs, []Indexable{jobs[0], jobs[1]}) {
+				t.Errorf("ElasticSearchAlertRepository.Match() docs = %v, want %v", gotDocs, jobs)
+			}
+		})
+	}
+}
+
+func TestElasticSearchAlertRepository_UpdateMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		mapping     string
+		initErr     error
+		wantMapping string
+		wantErr     bool
+	}{
+		{"invalid mapping keeps mapping", "{", nil, "old", true},
+		{"error keeps mapping", `{"mappings":{}}`, errors.New("error"), "old", true},
+		{"success", `{"mappings":{}}`, nil, `{"mappings":{}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ElasticSearchAlertRepository{repository: &mockRepository{initFn: func() error { return tt.initErr }}, mapping: "old"}
+			if err := r.UpdateMapping(context.TODO(), tt.mapping); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchAlertRepository.UpdateMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if r.mapping != tt.wantMapping || r.initialized["alerts"] == tt.wantErr {
+				t.Errorf("ElasticSearchAlertRepository.UpdateMapping() mapping = %v, initialized = %v, want %v", r.mapping, r.initialized, tt.wantMapping)
+			}
+		})
+	}
+}
+
+type mockAlertRepository struct {
+	registerFn      func(search SavedSearch) error
+	unregisterFn    func(id string) error
+	matchFn         func(jobs []Job) ([][]string, error)
+	updateMappingFn func(mapping string) error
+}
+
+func (r mockAlertRepository) Register(ctx context.Context, search SavedSearch) error {
+	return r.registerFn(search)
+}
+func (r mockAlertRepository) Unregister(ctx context.Context, id string) error {
+	return r.unregisterFn(id)
+}
+func (r mockAlertRepository) Match(ctx context.Context, jobs []Job) ([][]string, error) {
+	return r.matchFn(jobs)
+}
+func (r mockAlertRepository) UpdateMapping(ctx context.Context, mapping string) error {
+	return r.updateMappingFn(mapping)
+}
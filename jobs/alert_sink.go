@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AlertSink receives alerts of new jobs matching saved searches
+type AlertSink interface {
+	Send(ctx context.Context, alerts []Alert) error
+}
+
+// alert sinks
+const (
+	AlertSinkNone    = "none"
+	AlertSinkLog     = "log"
+	AlertSinkWebhook = "webhook"
+)
+
+// newAlertSink creates the alert sink of kind, nil when alerts are disabled
+func newAlertSink(kind, url, secret string, timeout time.Duration) (AlertSink, error) {
+	switch kind {
+	case AlertSinkNone:
+		return nil, nil
+	case AlertSinkLog:
+		return LogAlertSink{}, nil
+	case AlertSinkWebhook:
+		if url == "" || secret == "" {
+			return nil, fmt.Errorf("webhook alert sink requires url and secret")
+		}
+		return newWebhookAlertSink(url, secret, timeout), nil
+	}
+	return nil, fmt.Errorf("invalid alert sink: %s, use one of: %s, %s, %s", kind, AlertSinkNone, AlertSinkLog, AlertSinkWebhook)
+}
+
+// LogAlertSink AlertSink impl writing alerts on log
+type LogAlertSink struct{}
+
+// Send logs each alert with the ids of the matched jobs
+func (LogAlertSink) Send(ctx context.Context, alerts []Alert) error {
+	for _, alert := range alerts {
+		ids := make([]string, len(alert.Jobs))
+		for i, job := range alert.Jobs {
+			ids[i] = job.ID
+		}
+		log.Printf("message=\"job alert\" kind=alert search=%s size=%d jobs=%s", alert.SearchID, len(alert.Jobs), strings.Join(ids, ","))
+	}
+	return nil
+}
+
+// AlertSignatureHeader header with the hex HMAC-SHA256 of the webhook body, prefixed with 'sha256='
+const AlertSignatureHeader = "X-Jobs-Signature"
+
+// webhookRequest body posted to webhook
+type webhookRequest struct {
+	SentAt time.Time `json:"sentAt"`
+	Alerts []Alert   `json:"alerts"`
+}
+
+// WebhookAlertSink AlertSink impl posting alerts as json to url, the body is signed with secret on AlertSignatureHeader
+type WebhookAlertSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+	now    func() time.Time
+}
+
+// newWebhookAlertSink WebhookAlertSink constructor
+func newWebhookAlertSink(url, secret string, timeout time.Duration) *WebhookAlertSink {
+	return &WebhookAlertSink{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}, now: time.Now}
+}
+
+// Send posts all alerts on a single request, any status other than 2xx is an error
+func (s *WebhookAlertSink) Send(ctx context.Context, alerts []Alert) error {
+	body, err := json.Marshal(webhookRequest{SentAt: s.now().UTC(), Alerts: alerts})
+	if err != nil {
+		return NewParserError(fmt.Sprintf("error creating webhook body, message: %s", err.Error()))
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return NewUnknownError(fmt.Sprintf("error creating webhook request, message: %s", err.Error()))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AlertSignatureHeader, "sha256="+signAlerts(s.secret, body))
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return NewUnknownError(fmt.Sprintf("error posting alerts to webhook, message: %s", err.Error()))
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return NewHTTPError(resp.StatusCode, fmt.Sprintf("error posting alerts to webhook, status: %d", resp.StatusCode))
+	}
+	return nil
+}
+
+// signAlerts hex HMAC-SHA256 of body with secret
+func signAlerts(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_newAlertSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		url     string
+		secret  string
+		want    reflect.Type
+		wantErr bool
+	}{
+		{"none", AlertSinkNone, "", "", nil, false},
+		{"log", AlertSinkLog, "", "", reflect.TypeOf(LogAlertSink{}), false},
+		{"webhook", AlertSinkWebhook, "http://localhost/alerts", "secret", reflect.TypeOf(&WebhookAlertSink{}), false},
+		{"webhook without secret", AlertSinkWebhook, "http://localhost/alerts", "", nil, true},
+		{"invalid", "email", "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAlertSink(tt.kind, tt.url, tt.secret, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newAlertSink() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if reflect.TypeOf(got) != tt.want {
+				t.Errorf("newAlertSink() = %T, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookAlertSink_Send(t *testing.T) {
+	now := time.Date(2017, 1, 26, 2, 4, 51, 0, time.UTC)
+	alerts := []Alert{{SearchID: "s1", Jobs: []JobHit{{ID: "id1", Job: Job{Title: "Desenvolvedor Java"}}}}}
+	wantBody := `{"sentAt":"2017-01-26T02:04:51Z","alerts":[{"searchId":"s1","jobs":[{"id":"id1","title":"Desenvolvedor Java"}]}]}`
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"success", http.StatusNoContent, false},
+		{"status error", http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody, gotSignature, gotContentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				gotBody, gotSignature, gotContentType = string(body), r.Header.Get(AlertSignatureHeader), r.Header.Get("Content-Type")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			s := newWebhookAlertSink(server.URL, "secret", time.Second)
+			s.now = func() time.Time { return now }
+			if err := s.Send(context.TODO(), alerts); (err != nil) != tt.wantErr {
+				t.Errorf("WebhookAlertSink.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotBody != wantBody {
+				t.Errorf("WebhookAlertSink.Send() body = %v, want %v", gotBody, wantBody)
+			}
+			if want := "sha256=" + signAlerts([]byte("secret"), []byte(wantBody)); gotSignature != want {
+				t.Errorf("WebhookAlertSink.Send() signature = %v, want %v", gotSignature, want)
+			}
+			if gotContentType != "application/json" {
+				t.Errorf("WebhookAlertSink.Send() content type = %v, want application/json", gotContentType)
+			}
+		})
+	}
+}
+
+func TestWebhookAlertSink_Send_unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	if err := newWebhookAlertSink(server.URL, "secret", time.Second).Send(context.TODO(), []Alert{{SearchID: "s1"}}); err == nil {
+		t.Errorf("WebhookAlertSink.Send() expected error for unreachable webhook")
+	}
+}
+
+func Test_signAlerts(t *testing.T) {
+	// echo -n '{"alerts":[]}' | openssl dgst -sha256 -hmac secret
+	if got, want := signAlerts([]byte("secret"), []byte(`{"alerts":[]}`)), "1745765da3451aa4b37c66c165cd80b9a4765574a56eedd22aebc2737ac612c3"; got != want {
+		t.Errorf("signAlerts() = %v, want %v", got, want)
+	}
+}
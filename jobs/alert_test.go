@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_jobAlerter_alert(t *testing.T) {
+	java, golang := Job{Title: "Desenvolvedor Java"}, Job{Title: "Desenvolvedor Go"}
+	tests := []struct {
+		name       string
+		jobs       []Job
+		matches    [][]string
+		matchErr   error
+		wantAlerts []Alert
+		wantErr    bool
+	}{
+		{"no jobs", nil, nil, nil, nil, false},
+		{"match error", []Job{java}, nil, errors.New("error"), nil, true},
+		{"no matches", []Job{java, golang}, [][]string{nil, nil}, nil, nil, false},
+		{"grouped by search", []Job{java, golang}, [][]string{{"s1", "s2"}, {"s2"}}, nil, []Alert{
+			{SearchID: "s1", Jobs: []JobHit{{ID: java.ID(), Job: java}}},
+			{SearchID: "s2", Jobs: []JobHit{{ID: java.ID(), Job: java}, {ID: golang.ID(), Job: golang}}},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAlerts []Alert
+			a := newJobAlerter(&mockAlertRepository{matchFn: func(jobs []Job) ([][]string, error) { return tt.matches, tt.matchErr }}, mockAlertSink(func(alerts []Alert) error {
+				gotAlerts = alerts
+				return nil
+			}), 1, 0, 0)
+			defer a.close()
+			if err := a.alert(context.TODO(), tt.jobs); (err != nil) != tt.wantErr {
+				t.Errorf("jobAlerter.alert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotAlerts, tt.wantAlerts) {
+				t.Errorf("jobAlerter.alert() alerts = %+v, want %+v", gotAlerts, tt.wantAlerts)
+			}
+		})
+	}
+}
+
+func Test_jobAlerter_deliver(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		wantSends int
+	}{
+		{"success", 2, 0, 1},
+		{"success after retries", 2, 2, 3},
+		{"dropped after retries", 1, 5, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sends := 0
+			a := newJobAlerter(&mockAlertRepository{matchFn: func(jobs []Job) ([][]string, error) { return [][]string{{"s1"}}, nil }}, mockAlertSink(func(alerts []Alert) error {
+				sends++
+				if sends <= tt.failures {
+					return errors.New("error on sink")
+				}
+				return nil
+			}), 1, tt.retries, time.Millisecond)
+			a.enqueue(context.TODO(), []Job{Job{Title: "a"}})
+			a.close()
+			if sends != tt.wantSends {
+				t.Errorf("jobAlerter sends = %d, want %d", sends, tt.wantSends)
+			}
+		})
+	}
+}
+
+func Test_jobAlerter_enqueue(t *testing.T) {
+	release := make(chan bool)
+	var mutex sync.Mutex
+	var tenants []string
+	a := newJobAlerter(&mockAlertRepository{matchFn: func(jobs []Job) ([][]string, error) { return [][]string{{"s1"}}, nil }}, mockAlertSink(func(alerts []Alert) error {
+		<-release
+		mutex.Lock()
+		defer mutex.Unlock()
+		tenants = append(tenants, alerts[0].Tenant)
+		return nil
+	}), 1, 0, 0)
+
+	done := make(chan bool)
+	go func() {
+		for _, tenant := range []string{"acme", "globex", "initech", "umbrella"} {
+			a.enqueue(ContextWithTenant(context.TODO(), tenant), []Job{Job{Title: "a"}})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("jobAlerter.enqueue() blocked while sink is slow")
+	}
+	close(release)
+	a.close()
+
+	if len(tenants) < 1 || len(tenants) > 2 || tenants[0] != "acme" {
+		t.Errorf("jobAlerter alerted tenants = %v, want acme and at most one queued batch", tenants)
+	}
+}
+
+type mockAlertSink func(alerts []Alert) error
+
+func (s mockAlertSink) Send(ctx context.Context, alerts []Alert) error {
+	return s(alerts)
+}
@@ -0,0 +1,144 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// api key scopes, ScopeAdmin has every scope
+const (
+	ScopeJobsWrite  = "jobs:write"
+	ScopeJobsDelete = "jobs:delete"
+	ScopeAdmin      = "admin"
+)
+
+var apiKeyScopes = map[string]bool{ScopeJobsWrite: true, ScopeJobsDelete: true, ScopeAdmin: true}
+
+// APIKey client allowed to call write endpoints with Scopes, identified by Name, restricted to Tenant when set
+type APIKey struct {
+	Name   string
+	Tenant string
+	Scopes []string
+}
+
+// HasScope checks if the key has scope or admin scope, every key has the empty scope
+func (k APIKey) HasScope(scope string) bool {
+	return hasScope(k.Scopes, scope)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	if scope == "" { //reads only require a valid credential
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type subjectKey struct{}
+
+// ContextWithSubject context with the authenticated subject of the request, the api key name or the token subject
+func ContextWithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext authenticated subject of the request, empty for anonymous requests
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// APIKeyStore api keys by the hex sha-256 hash of the key, keys are never stored in plain text
+type APIKeyStore struct {
+	keys map[string]APIKey
+}
+
+var apiKeyHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LoadAPIKeys loads api keys from the file on path and from keys, entries are 'name:sha256:scope,scope' separated by new lines or ';',
+// keys of a tenant are named 'name@tenant', empty lines and lines starting with '#' are ignored
+func LoadAPIKeys(path, keys string) (*APIKeyStore, error) {
+	content := keys
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading api keys file %s, message: %s", path, err.Error())
+		}
+		content = string(b) + "\n" + keys
+	}
+	return parseAPIKeys(content)
+}
+
+func parseAPIKeys(content string) (*APIKeyStore, error) {
+	store := &APIKeyStore{keys: make(map[string]APIKey)}
+	names := make(map[string]bool)
+	for _, entry := range strings.FieldsFunc(content, func(r rune) bool { return r == '\n' || r == ';' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid api key entry: %s, use name:sha256:scope,scope", entry)
+		}
+		name, hash := strings.TrimSpace(parts[0]), strings.ToLower(strings.TrimSpace(parts[1]))
+		if name == "" || names[name] {
+			return nil, fmt.Errorf("invalid api key name: '%s', names must be unique", name)
+		}
+		if !apiKeyHash.MatchString(hash) {
+			return nil, fmt.Errorf("invalid api key hash for %s, use the hex sha-256 of the key", name)
+		}
+		if _, ok := store.keys[hash]; ok {
+			return nil, fmt.Errorf("duplicated api key hash for %s", name)
+		}
+
+		key := APIKey{Name: name}
+		if n := strings.LastIndex(name, "@"); n >= 0 {
+			if !tenantName.MatchString(name[n+1:]) {
+				return nil, fmt.Errorf("invalid api key tenant for %s", name)
+			}
+			key.Tenant = name[n+1:]
+		}
+		for _, scope := range strings.Split(strings.Join(parts[2:], ":"), ",") {
+			if scope = strings.TrimSpace(scope); !apiKeyScopes[scope] {
+				return nil, fmt.Errorf("invalid api key scope for %s: '%s', use %s, %s or %s", name, scope, ScopeJobsWrite, ScopeJobsDelete, ScopeAdmin)
+			}
+			key.Scopes = append(key.Scopes, scope)
+		}
+		names[name] = true
+		store.keys[hash] = key
+	}
+	return store, nil
+}
+
+// HashAPIKey hex sha-256 hash of key, as stored on api key entries
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authorize finds the api key with scope, unauthorized when key is missing or unknown and forbidden without scope
+func (s *APIKeyStore) Authorize(ctx context.Context, key, scope string) (*APIKey, error) {
+	if key == "" {
+		return nil, NewUnauthorizedError("api key is required")
+	}
+	if s == nil {
+		return nil, NewUnauthorizedError("invalid api key")
+	}
+	apiKey, ok := s.keys[HashAPIKey(key)]
+	if !ok {
+		return nil, NewUnauthorizedError("invalid api key")
+	}
+	if !apiKey.HasScope(scope) {
+		return nil, NewForbiddenError(fmt.Sprintf("api key %s has no scope %s", apiKey.Name, scope))
+	}
+	return &apiKey, nil
+}
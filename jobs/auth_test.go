@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_parseAPIKeys(t *testing.T) {
+	hash := HashAPIKey("key")
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]APIKey
+		wantErr bool
+	}{
+		{"empty", "", map[string]APIKey{}, false},
+		{"single", "ingester:" + hash + ":jobs:write,jobs:delete", map[string]APIKey{hash: {Name: "ingester", Scopes: []string{ScopeJobsWrite, ScopeJobsDelete}}}, false},
+		{"comments and separators", "# keys\n\n ops:" + hash + ":admin ;writer:" + HashAPIKey("other") + ":jobs:write\n", map[string]APIKey{hash: {Name: "ops", Scopes: []string{ScopeAdmin}}, HashAPIKey("other"): {Name: "writer", Scopes: []string{ScopeJobsWrite}}}, false},
+		{"uppercase hash", "ops:" + "A665A45920422F9D417E4867EFDC4FB8A04A1F3FFF1FA07E998E86F7F7A27AE3" + ":admin", map[string]APIKey{HashAPIKey("123"): {Name: "ops", Scopes: []string{ScopeAdmin}}}, false},
+		{"tenant key", "ingester@acme:" + hash + ":jobs:write", map[string]APIKey{hash: {Name: "ingester@acme", Tenant: "acme", Scopes: []string{ScopeJobsWrite}}}, false},
+		{"invalid tenant", "ingester@Acme:" + hash + ":jobs:write", nil, true},
+		{"missing scopes", "ops:" + hash, nil, true},
+		{"invalid hash", "ops:key:admin", nil, true},
+		{"unknown scope", "ops:" + hash + ":jobs:read", nil, true},
+		{"duplicated name", "ops:" + hash + ":admin;ops:" + HashAPIKey("other") + ":admin", nil, true},
+		{"duplicated hash", "ops:" + hash + ":admin;writer:" + hash + ":jobs:write", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAPIKeys(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseAPIKeys() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !reflect.DeepEqual(got.keys, tt.want) {
+				t.Errorf("parseAPIKeys() = %v, want %v", got.keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "api-keys")
+	ioutil.WriteFile(path, []byte("ops:"+HashAPIKey("ops-key")+":admin\n"), 0600)
+
+	store, err := LoadAPIKeys(path, "writer:"+HashAPIKey("writer-key")+":jobs:write")
+	if err != nil {
+		t.Fatalf("LoadAPIKeys() error = %v", err)
+	}
+	if len(store.keys) != 2 {
+		t.Errorf("LoadAPIKeys() = %v, want keys from file and env", store.keys)
+	}
+	if _, err := LoadAPIKeys(filepath.Join(dir, "missing"), ""); err == nil {
+		t.Errorf("LoadAPIKeys() missing file error = nil, want error")
+	}
+}
+
+func TestAPIKeyStore_Authorize(t *testing.T) {
+	store, _ := parseAPIKeys("ops:" + HashAPIKey("ops-key") + ":admin;writer:" + HashAPIKey("writer-key") + ":jobs:write")
+	tests := []struct {
+		name      string
+		store     *APIKeyStore
+		key       string
+		scope     string
+		wantName  string
+		wantError ErrorType
+	}{
+		{"write scope", store, "writer-key", ScopeJobsWrite, "writer", 0},
+		{"admin has every scope", store, "ops-key", ScopeJobsDelete, "ops", 0},
+		{"missing key", store, "", ScopeJobsWrite, "", ERROR_UNAUTHORIZED},
+		{"invalid key", store, "unknown", ScopeJobsWrite, "", ERROR_UNAUTHORIZED},
+		{"missing scope", store, "writer-key", ScopeJobsDelete, "", ERROR_FORBIDDEN},
+		{"no keys configured", nil, "writer-key", ScopeJobsWrite, "", ERROR_UNAUTHORIZED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.store.Authorize(context.TODO(), tt.key, tt.scope)
+			if tt.wantError != 0 {
+				if jobErr, ok := err.(*JobError); !ok || jobErr.ErrType != tt.wantError || jobErr.ErrCode != JOB1004 {
+					t.Errorf("APIKeyStore.Authorize() error = %v, want type %d", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil || got.Name != tt.wantName {
+				t.Errorf("APIKeyStore.Authorize() = %v, %v, want %s", got, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestSubjectFromContext(t *testing.T) {
+	if got := SubjectFromContext(context.TODO()); got != "" {
+		t.Errorf("SubjectFromContext() = %s, want anonymous", got)
+	}
+	if got := SubjectFromContext(ContextWithSubject(context.TODO(), "ingester")); got != "ingester" {
+		t.Errorf("SubjectFromContext() = %s, want ingester", got)
+	}
+}
@@ -19,11 +19,48 @@ type config struct {
 	APP  string `env:"JOBS_APP_NAME" envDefault:"c-jobs"`
 	Port int    `env:"JOBS_PORT" envDefault:"8080"`
 
+	Repository            string `env:"JOBS_REPOSITORY" envDefault:"elasticsearch"`
+	DiskPath              string `env:"JOBS_DISK_PATH" envDefault:"data"`
+	DiskCompactOperations int    `env:"JOBS_DISK_COMPACT_OPERATIONS" envDefault:"10000"`
+	Tenants               string `env:"JOBS_TENANTS" envDefault:""`
+
 	ElasticSearchServer             string `env:"JOBS_ELASTICSEARCH_SERVER" envDefault:"http://localhost:9200"`
 	ElasticSearchMaxRetry           int    `env:"JOBS_ELASTICSEARCH_MAX_RETRY" envDefault:"3"`
 	ElasticSearchSniff              bool   `env:"JOBS_ELASTICSEARCH_SNIFF" envDefault:"false"`
 	ElasticSearchReconnectRetryTime int    `env:"JOBS_ELASTICSEARCH_RECONNECT_RETRY_TIME_SECONDS" envDefault:"5"`
 	ElasticSearchIndexMappingPath   string `env:"JOBS_ELASTICSEARCH_INDEX_MAPPING_PATH" envDefault:"cfg/jobs-mapping.json"`
+	ElasticSearchSynonymsPath       string `env:"JOBS_ELASTICSEARCH_SYNONYMS_PATH" envDefault:"cfg/jobs-synonyms.txt"`
+
+	SearchSalaryBuckets         string `env:"JOBS_SEARCH_SALARY_BUCKETS" envDefault:"1000,2000,3000,5000,10000"`
+	SearchHighlightPreTag       string `env:"JOBS_SEARCH_HIGHLIGHT_PRE_TAG" envDefault:"<em>"`
+	SearchHighlightPostTag      string `env:"JOBS_SEARCH_HIGHLIGHT_POST_TAG" envDefault:"</em>"`
+	SearchHighlightFragmentSize int    `env:"JOBS_SEARCH_HIGHLIGHT_FRAGMENT_SIZE" envDefault:"150"`
+	SearchHighlightFragments    int    `env:"JOBS_SEARCH_HIGHLIGHT_FRAGMENTS" envDefault:"3"`
+
+	IngestionWorkers       int `env:"JOBS_INGESTION_WORKERS" envDefault:"4"`
+	IngestionBatchSize     int `env:"JOBS_INGESTION_BATCH_SIZE" envDefault:"500"`
+	IngestionFlushInterval int `env:"JOBS_INGESTION_FLUSH_INTERVAL_MILLISECONDS" envDefault:"1000"`
+	IngestionFlushTimeout  int `env:"JOBS_INGESTION_FLUSH_TIMEOUT_SECONDS" envDefault:"60"`
+	IngestionQueueSize     int `env:"JOBS_INGESTION_QUEUE_SIZE" envDefault:"10000"`
+	IngestionRetention     int `env:"JOBS_INGESTION_RETENTION_MINUTES" envDefault:"60"`
+	IngestionMaxTracked    int `env:"JOBS_INGESTION_MAX_TRACKED" envDefault:"1000"`
+
+	APIKeys     string `env:"JOBS_API_KEYS" envDefault:""`
+	APIKeysPath string `env:"JOBS_API_KEYS_PATH" envDefault:""`
+
+	JWTSecret   string `env:"JOBS_JWT_SECRET" envDefault:""`
+	JWTJWKSPath string `env:"JOBS_JWT_JWKS_PATH" envDefault:""`
+	JWTAudience string `env:"JOBS_JWT_AUDIENCE" envDefault:""`
+	JWTIssuer   string `env:"JOBS_JWT_ISSUER" envDefault:""`
+	JWTLeeway   int    `env:"JOBS_JWT_LEEWAY_SECONDS" envDefault:"30"`
+
+	AlertSink           string `env:"JOBS_ALERT_SINK" envDefault:"log"`
+	AlertWebhookURL     string `env:"JOBS_ALERT_WEBHOOK_URL" envDefault:""`
+	AlertWebhookSecret  string `env:"JOBS_ALERT_WEBHOOK_SECRET" envDefault:""`
+	AlertWebhookTimeout int    `env:"JOBS_ALERT_WEBHOOK_TIMEOUT_MILLISECONDS" envDefault:"5000"`
+	AlertQueueSize      int    `env:"JOBS_ALERT_QUEUE_SIZE" envDefault:"1000"`
+	AlertRetries        int    `env:"JOBS_ALERT_RETRIES" envDefault:"3"`
+	AlertRetryWait      int    `env:"JOBS_ALERT_RETRY_WAIT_MILLISECONDS" envDefault:"1000"`
 }
 
 var mutex sync.RWMutex
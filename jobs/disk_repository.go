@@ -0,0 +1,495 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DiskRepository Repository impl persisted on a data directory, searches use the same inverted index of InMemoryRepository.
+// each index has a snapshot with documents, inverted index and percolators and an append only log with the operations after it,
+// the log is written and synced before operations are applied and replaced by a new snapshot after compactOperations
+type DiskRepository struct {
+	memory            *InMemoryRepository
+	path              string
+	compactOperations int
+	logs              map[string]*diskLog
+	mutex             sync.Mutex
+}
+
+// diskLog open operations log of an index and the number of operations after the snapshot
+type diskLog struct {
+	file       *os.File
+	operations int
+}
+
+// operations on the log
+const (
+	diskAdd           = "add"
+	diskDelete        = "delete"
+	diskAddPercolator = "add_percolator"
+)
+
+// index files on data directory, named after the index
+const (
+	diskSnapshotSuffix = ".snapshot"
+	diskLogSuffix      = ".log"
+	diskTempSuffix     = ".tmp"
+)
+
+// DefaultCompactOperations operations on the log before a new snapshot
+const DefaultCompactOperations = 10000
+
+// diskOperation operation on the log, one json per line
+type diskOperation struct {
+	Op      string          `json:"op"`
+	Type    string          `json:"type,omitempty"`
+	ID      string          `json:"id"`
+	Source  json.RawMessage `json:"source,omitempty"`
+	Queries []Query         `json:"queries,omitempty"`
+}
+
+// diskSnapshot state of an index
+type diskSnapshot struct {
+	Docs        map[string]diskDocument
+	Postings    map[string]map[string]map[string][]int
+	Percolators map[string][]Query
+}
+
+type diskDocument struct {
+	Type   string
+	Source []byte
+	Tokens map[string][]string
+}
+
+var diskIndexName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// newDiskRepository DiskRepository constructor, loads every index found on path
+func newDiskRepository(path string, compactOperations int) (*DiskRepository, error) {
+	if compactOperations < 1 {
+		compactOperations = DefaultCompactOperations
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, NewDiskAccessError(fmt.Sprintf("error creating data directory, message: %s", err.Error()))
+	}
+	d := &DiskRepository{memory: newInMemoryRepository(), path: path, compactOperations: compactOperations, logs: make(map[string]*diskLog)}
+
+	files, err := filepath.Glob(filepath.Join(path, "*"+diskLogSuffix))
+	if err != nil {
+		return nil, NewDiskAccessError(fmt.Sprintf("error listing data directory, message: %s", err.Error()))
+	}
+	for _, file := range files {
+		if err := d.load(strings.TrimSuffix(filepath.Base(file), diskLogSuffix)); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// load reads the snapshot and replays the log of index, a partially written last operation is discarded
+func (d *DiskRepository) load(index string) error {
+	i := newMemoryIndex()
+	if f, err := os.Open(d.file(index, diskSnapshotSuffix)); err == nil {
+		var snapshot diskSnapshot
+		err = gob.NewDecoder(f).Decode(&snapshot)
+		f.Close()
+		if err != nil {
+			return NewDiskAccessError(fmt.Sprintf("error reading snapshot of %s, message: %s", index, err.Error()))
+		}
+		if i, err = fromSnapshot(snapshot); err != nil {
+			return NewDiskAccessError(fmt.Sprintf("error reading snapshot of %s, message: %s", index, err.Error()))
+		}
+	} else if !os.IsNotExist(err) {
+		return NewDiskAccessError(fmt.Sprintf("error opening snapshot of %s, message: %s", index, err.Error()))
+	}
+
+	file, err := os.OpenFile(d.file(index, diskLogSuffix), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return NewDiskAccessError(fmt.Sprintf("error opening log of %s, message: %s", index, err.Error()))
+	}
+	operations, size := 0, int64(0)
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("message=\"discarding incomplete operation\" kind=disk index=%s offset=%d", index, size)
+			}
+			break
+		}
+		if err != nil {
+			file.Close()
+			return NewDiskAccessError(fmt.Sprintf("error reading log of %s, message: %s", index, err.Error()))
+		}
+		var op diskOperation
+		if err := json.Unmarshal(line, &op); err != nil {
+			file.Close()
+			return NewDiskAccessError(fmt.Sprintf("error reading log of %s at offset %d, message: %s", index, size, err.Error()))
+		}
+		i.apply(op)
+		operations++
+		size += int64(len(line))
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return NewDiskAccessError(fmt.Sprintf("error truncating log of %s, message: %s", index, err.Error()))
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return NewDiskAccessError(fmt.Sprintf("error opening log of %s, message: %s", index, err.Error()))
+	}
+
+	d.memory.rmutex.Lock()
+	d.memory.indexes[index] = i
+	d.memory.rmutex.Unlock()
+	d.logs[index] = &diskLog{file: file, operations: operations}
+	log.Printf("message=\"index loaded\" kind=disk index=%s docs=%d operations=%d", index, len(i.docs), operations)
+	return nil
+}
+
+func (d *DiskRepository) file(index, suffix string) string {
+	return filepath.Join(d.path, index+suffix)
+}
+
+// openLog opens the log of index, creating the index when missing
+func (d *DiskRepository) openLog(index string) (*diskLog, error) {
+	if l, ok := d.logs[index]; ok {
+		return l, nil
+	}
+	if !diskIndexName.MatchString(index) {
+		return nil, NewInvalidRequestError(fmt.Sprintf("invalid index name: %s", index))
+	}
+	if err := d.load(index); err != nil {
+		return nil, err
+	}
+	return d.logs[index], nil
+}
+
+// write appends operations to the log of index and syncs it, then applies them and compacts the index when needed
+func (d *DiskRepository) write(index string, ops ...diskOperation) ([]bool, error) {
+	l, err := d.openLog(index)
+	if err != nil {
+		return nil, err
+	}
+	var buffer []byte
+	for _, op := range ops {
+		b, err := json.Marshal(op)
+		if err != nil {
+			return nil, NewParserError(fmt.Sprintf("error writing operation, message: %s", err.Error()))
+		}
+		buffer = append(append(buffer, b...), '\n')
+	}
+	if _, err := l.file.Write(buffer); err != nil {
+		return nil, NewDiskAccessError(fmt.Sprintf("error writing log of %s, message: %s", index, err.Error()))
+	}
+	if err := l.file.Sync(); err != nil {
+		return nil, NewDiskAccessError(fmt.Sprintf("error syncing log of %s, message: %s", index, err.Error()))
+	}
+
+	d.memory.rmutex.Lock()
+	i := d.memory.index(index)
+	created := make([]bool, len(ops))
+	for o, op := range ops {
+		created[o] = i.apply(op)
+	}
+	d.memory.rmutex.Unlock()
+
+	l.operations += len(ops)
+	if l.operations >= d.compactOperations {
+		d.compact(index, l)
+	}
+	return created, nil
+}
+
+// compact writes a new snapshot of index and truncates its log, on failure the log is kept and replayed on load.
+// the snapshot shares maps with the index, it is safe to encode without the index lock while holding the write mutex
+func (d *DiskRepository) compact(index string, l *diskLog) {
+	d.memory.rmutex.RLock()
+	snapshot := d.memory.indexes[index].snapshot()
+	d.memory.rmutex.RUnlock()
+
+	if err := writeSnapshot(d.file(index, diskSnapshotSuffix), snapshot); err != nil {
+		log.Printf("message=\"error writing snapshot\" kind=disk index=%s error=\"%s\"", index, err.Error())
+		return
+	}
+	if err := l.file.Truncate(0); err != nil {
+		log.Printf("message=\"error truncating log\" kind=disk index=%s error=\"%s\"", index, err.Error())
+		return
+	}
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		log.Printf("message=\"error truncating log\" kind=disk index=%s error=\"%s\"", index, err.Error())
+		return
+	}
+	log.Printf("message=\"index compacted\" kind=disk index=%s docs=%d operations=%d", index, len(snapshot.Docs), l.operations)
+	l.operations = 0
+}
+
+// writeSnapshot writes on a temporary file renamed over the previous snapshot
+func writeSnapshot(path string, snapshot diskSnapshot) error {
+	f, err := os.Create(path + diskTempSuffix)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(snapshot); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+diskTempSuffix, path)
+}
+
+// apply replays an operation, operations are idempotent, returns true when a document is created
+func (i *memoryIndex) apply(op diskOperation) bool {
+	switch op.Op {
+	case diskAdd:
+		created, err := i.addSource(op.ID, op.Type, op.Source)
+		if err != nil {
+			log.Printf("message=\"error applying operation\" kind=disk op=%s id=%s error=\"%s\"", op.Op, op.ID, err.Error())
+		}
+		return created
+	case diskDelete:
+		i.delete(op.Type, op.ID)
+	case diskAddPercolator:
+		i.percolators[op.ID] = op.Queries
+	}
+	return false
+}
+
+func (i *memoryIndex) snapshot() diskSnapshot {
+	snapshot := diskSnapshot{Docs: make(map[string]diskDocument, len(i.docs)), Postings: i.postings, Percolators: i.percolators}
+	for id, doc := range i.docs {
+		snapshot.Docs[id] = diskDocument{Type: doc.typ, Source: doc.source, Tokens: doc.tokens}
+	}
+	return snapshot
+}
+
+func fromSnapshot(snapshot diskSnapshot) (*memoryIndex, error) {
+	i := newMemoryIndex()
+	for id, doc := range snapshot.Docs {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(doc.Source, &fields); err != nil {
+			return nil, err
+		}
+		tokens := doc.Tokens
+		if tokens == nil {
+			tokens = make(map[string][]string)
+		}
+		i.docs[id] = &memoryDocument{id: id, typ: doc.Type, source: doc.Source, fields: fields, tokens: tokens}
+	}
+	if snapshot.Postings != nil {
+		i.postings = snapshot.Postings
+	}
+	if snapshot.Percolators != nil {
+		i.percolators = snapshot.Percolators
+	}
+	return i, nil
+}
+
+// InitIndex creates the index files when missing, mapping is ignored
+func (d *DiskRepository) InitIndex(ctx context.Context, name, mapping string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	_, err := d.openLog(name)
+	return err
+}
+
+// Add add content do index
+func (d *DiskRepository) Add(ctx context.Context, index string, content Indexable) error {
+	op, jobErr := addOperation(content)
+	if jobErr != nil {
+		return jobErr
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	_, err := d.write(index, op)
+	return err
+}
+
+// BulkAdd add all contents to index with a single log write, returns the result of each item
+func (d *DiskRepository) BulkAdd(ctx context.Context, index string, items []Indexable) ([]BulkItemResult, error) {
+	if len(items) < 1 {
+		return nil, NewInvalidRequestError("items is empty")
+	}
+
+	result := make([]BulkItemResult, len(items))
+	var ops []diskOperation
+	var positions []int
+	for n, item := range items {
+		op, err := addOperation(item)
+		if err != nil {
+			result[n] = BulkItemResult{ID: item.ID(), Result: ItemFailed, Error: err}
+			continue
+		}
+		ops = append(ops, op)
+		positions = append(positions, n)
+	}
+	if len(ops) == 0 {
+		return result, nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	created, err := d.write(index, ops...)
+	if err != nil {
+		return nil, err
+	}
+	for o, n := range positions {
+		result[n] = BulkItemResult{ID: items[n].ID(), Result: ItemUpdated}
+		if created[o] {
+			result[n].Result = ItemCreated
+		}
+	}
+	return result, nil
+}
+
+func addOperation(content Indexable) (diskOperation, *JobError) {
+	source, err := json.Marshal(content)
+	if err != nil {
+		return diskOperation{}, NewParserError(fmt.Sprintf("error indexing content, message: %s", err.Error()))
+	}
+	return diskOperation{Op: diskAdd, Type: indexType(content), ID: content.ID(), Source: source}, nil
+}
+
+// Delete remove content from index by id, percolator type removes stored queries
+func (d *DiskRepository) Delete(ctx context.Context, index, typ, id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.exists(index, typ, id) {
+		return NewNotFoundError(fmt.Sprintf("id %s not found", id))
+	}
+	_, err := d.write(index, diskOperation{Op: diskDelete, Type: typ, ID: id})
+	return err
+}
+
+// BulkDelete remove contents from index by id with a single log write, returns the result of each id
+func (d *DiskRepository) BulkDelete(ctx context.Context, index, typ string, ids []string) ([]BulkItemResult, error) {
+	if len(ids) < 1 {
+		return nil, NewInvalidRequestError("ids is empty")
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := make([]BulkItemResult, len(ids))
+	var ops []diskOperation
+	for n, id := range ids {
+		if !d.exists(index, typ, id) {
+			result[n] = BulkItemResult{ID: id, Result: ItemFailed, Error: NewNotFoundError(fmt.Sprintf("id %s not found", id))}
+			continue
+		}
+		ops = append(ops, diskOperation{Op: diskDelete, Type: typ, ID: id})
+		result[n] = BulkItemResult{ID: id, Result: ItemDeleted}
+	}
+	if len(ops) == 0 {
+		return result, nil
+	}
+	if _, err := d.write(index, ops...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// exists checks if a document or percolator exists, used before deleting to keep failed deletes out of the log
+func (d *DiskRepository) exists(index, typ, id string) bool {
+	d.memory.rmutex.RLock()
+	defer d.memory.rmutex.RUnlock()
+	i, ok := d.memory.indexes[index]
+	if !ok {
+		return false
+	}
+	if typ == percolatorType {
+		_, ok := i.percolators[id]
+		return ok
+	}
+	doc, ok := i.docs[id]
+	return ok && doc.typ == typ
+}
+
+// DeleteByQuery remove all contents on index matching queries, matches are logged as deletes by id
+func (d *DiskRepository) DeleteByQuery(ctx context.Context, index string, queries ...Query) (*DeleteByQueryResult, error) {
+	if len(queries) < 1 {
+		return nil, NewInvalidRequestError("queries is empty")
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var ops []diskOperation
+	d.memory.rmutex.RLock()
+	if i, ok := d.memory.indexes[index]; ok {
+		for id := range i.match(Query{Bool: &Bool{Must: queries}}) {
+			ops = append(ops, diskOperation{Op: diskDelete, Type: i.docs[id].typ, ID: id})
+		}
+	}
+	d.memory.rmutex.RUnlock()
+	if len(ops) == 0 {
+		return &DeleteByQueryResult{}, nil
+	}
+	if _, err := d.write(index, ops...); err != nil {
+		return nil, err
+	}
+	return &DeleteByQueryResult{Matched: int64(len(ops)), Deleted: int64(len(ops))}, nil
+}
+
+// AddPercolator stores queries with id on index, documents matching the queries are found by Percolate
+func (d *DiskRepository) AddPercolator(ctx context.Context, index, id string, queries ...Query) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	_, err := d.write(index, diskOperation{Op: diskAddPercolator, ID: id, Queries: queries})
+	return err
+}
+
+// Get get content from index by id
+func (d *DiskRepository) Get(ctx context.Context, index, id string) (json.RawMessage, error) {
+	return d.memory.Get(ctx, index, id)
+}
+
+// Count count contents on index matching queries
+func (d *DiskRepository) Count(ctx context.Context, index string, queries ...Query) (int64, error) {
+	return d.memory.Count(ctx, index, queries...)
+}
+
+// Search search content on index, with the same semantics of InMemoryRepository
+func (d *DiskRepository) Search(ctx context.Context, index string, sorts []Sort, page *Page, facets []Facet, highlight *Highlight, queries ...Query) (*SearchResult, error) {
+	return d.memory.Search(ctx, index, sorts, page, facets, highlight, queries...)
+}
+
+// Suggest completes prefix with the distinct values of field starting with prefix
+func (d *DiskRepository) Suggest(ctx context.Context, index, field, prefix string, size int) ([]Suggestion, error) {
+	return d.memory.Suggest(ctx, index, field, prefix, size)
+}
+
+// Percolate finds the ids of the stored queries matching each document, on the same order the documents were sent
+func (d *DiskRepository) Percolate(ctx context.Context, index string, docs []Indexable) ([][]string, error) {
+	return d.memory.Percolate(ctx, index, docs)
+}
+
+// Close compacts and closes the logs of every index
+func (d *DiskRepository) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var result error
+	for index, l := range d.logs {
+		if l.operations > 0 {
+			d.compact(index, l)
+		}
+		if err := l.file.Close(); err != nil && result == nil {
+			result = NewDiskAccessError(fmt.Sprintf("error closing log of %s, message: %s", index, err.Error()))
+		}
+	}
+	d.logs = make(map[string]*diskLog)
+	return result
+}
@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newDiskTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func reopenDiskRepository(t *testing.T, d *DiskRepository, dir string, compactOperations int) *DiskRepository {
+	if d != nil {
+		if err := d.Close(); err != nil {
+			t.Fatalf("DiskRepository.Close() error = %v", err)
+		}
+	}
+	d, err := newDiskRepository(dir, compactOperations)
+	if err != nil {
+		t.Fatalf("newDiskRepository() error = %v", err)
+	}
+	return d
+}
+
+func TestDiskRepository_Reload(t *testing.T) {
+	tests := []struct {
+		name              string
+		compactOperations int
+		close             bool
+		wantSnapshot      bool
+	}{
+		{"replays log", 1000, false, false},
+		{"compacts after operations", 2, false, true},
+		{"compacts on close", 1000, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newDiskTestDir(t)
+			defer os.RemoveAll(dir)
+
+			d := reopenDiskRepository(t, nil, dir, tt.compactOperations)
+			items := make([]Indexable, len(memoryJobs))
+			for i, job := range memoryJobs {
+				items[i] = job
+			}
+			if _, err := d.BulkAdd(context.TODO(), "jobs", items); err != nil {
+				t.Fatalf("DiskRepository.BulkAdd() error = %v", err)
+			}
+			if err := d.Delete(context.TODO(), "jobs", "job", memoryJobs[3].ID()); err != nil {
+				t.Fatalf("DiskRepository.Delete() error = %v", err)
+			}
+			if _, err := d.DeleteByQuery(context.TODO(), "jobs", Query{Value: "estagio", Fields: []string{"title"}}); err != nil {
+				t.Fatalf("DiskRepository.DeleteByQuery() error = %v", err)
+			}
+			if err := d.AddPercolator(context.TODO(), "alerts", "java", Query{Value: "java", Fields: []string{"title"}, Operator: "and"}); err != nil {
+				t.Fatalf("DiskRepository.AddPercolator() error = %v", err)
+			}
+
+			if tt.close {
+				d = reopenDiskRepository(t, d, dir, tt.compactOperations)
+			} else {
+				d = reopenDiskRepository(t, nil, dir, tt.compactOperations)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "jobs.snapshot")); (err == nil) != tt.wantSnapshot {
+				t.Errorf("jobs.snapshot exists = %v, want %v", err == nil, tt.wantSnapshot)
+			}
+
+			result, err := d.Search(context.TODO(), "jobs", []Sort{{Field: "salario"}}, nil, nil, nil, Query{Value: "desenvolv*", Fields: []string{"title", "description"}})
+			if err != nil {
+				t.Fatalf("DiskRepository.Search() error = %v", err)
+			}
+			if got, want := memoryTitles(t, result.Hits), []string{"Desenvolvedor Golang", "Analista de Sistemas Java"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("DiskRepository.Search() = %v, want %v", got, want)
+			}
+			if count, _ := d.Count(context.TODO(), "jobs", Query{Bool: &Bool{}}); count != 2 {
+				t.Errorf("DiskRepository.Count() = %d, want 2", count)
+			}
+			if _, err := d.Get(context.TODO(), "jobs", memoryJobs[3].ID()); err == nil {
+				t.Errorf("DiskRepository.Get() deleted job found after reload")
+			}
+			if got, _ := d.Percolate(context.TODO(), "alerts", []Indexable{memoryJobs[0]}); !reflect.DeepEqual(got, [][]string{{"java"}}) {
+				t.Errorf("DiskRepository.Percolate() = %v, want [[java]]", got)
+			}
+			d.Close()
+		})
+	}
+}
+
+func TestDiskRepository_BulkResults(t *testing.T) {
+	dir := newDiskTestDir(t)
+	defer os.RemoveAll(dir)
+	d := reopenDiskRepository(t, nil, dir, 1000)
+	defer d.Close()
+
+	d.Add(context.TODO(), "jobs", memoryJobs[0])
+	results, err := d.BulkAdd(context.TODO(), "jobs", []Indexable{memoryJobs[0], memoryJobs[1]})
+	if err != nil || results[0].Result != ItemUpdated || results[1].Result != ItemCreated {
+		t.Errorf("DiskRepository.BulkAdd() = %+v, %v, want updated and created", results, err)
+	}
+	results, err = d.BulkDelete(context.TODO(), "jobs", "job", []string{memoryJobs[0].ID(), memoryJobs[3].ID()})
+	if err != nil || results[0].Result != ItemDeleted || results[1].Result != ItemFailed || results[1].Error.ErrCode != JOB1002 {
+		t.Errorf("DiskRepository.BulkDelete() = %+v, %v, want deleted and not found", results, err)
+	}
+	if err := d.Delete(context.TODO(), "jobs", "job", memoryJobs[3].ID()); err == nil || err.(*JobError).ErrCode != JOB1002 {
+		t.Errorf("DiskRepository.Delete() error = %v, want not found", err)
+	}
+	if err := d.InitIndex(context.TODO(), "../jobs", ""); err == nil || err.(*JobError).ErrCode != JOB1001 {
+		t.Errorf("DiskRepository.InitIndex() error = %v, want invalid index name", err)
+	}
+}
+
+func TestDiskRepository_Load(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      string
+		wantDocs int
+		wantLog  string
+		wantErr  bool
+	}{
+		{"empty", "", 0, "", false},
+		{"incomplete operation", `{"op":"add","type":"job","id":"a","source":{"title":"java"}}` + "\n" + `{"op":"add","type":"job","id":"b","sou`, 1, `{"op":"add","type":"job","id":"a","source":{"title":"java"}}` + "\n", false},
+		{"delete", `{"op":"add","type":"job","id":"a","source":{}}` + "\n" + `{"op":"delete","type":"job","id":"a"}` + "\n", 0, `{"op":"add","type":"job","id":"a","source":{}}` + "\n" + `{"op":"delete","type":"job","id":"a"}` + "\n", false},
+		{"corrupted operation", "invalid\n" + `{"op":"add","type":"job","id":"a","source":{}}` + "\n", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newDiskTestDir(t)
+			defer os.RemoveAll(dir)
+			ioutil.WriteFile(filepath.Join(dir, "jobs.log"), []byte(tt.log), 0644)
+
+			d, err := newDiskRepository(dir, 1000)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDiskRepository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer d.Close()
+			if count, _ := d.Count(context.TODO(), "jobs", Query{Bool: &Bool{}}); count != int64(tt.wantDocs) {
+				t.Errorf("DiskRepository.Count() = %d, want %d", count, tt.wantDocs)
+			}
+			if b, _ := ioutil.ReadFile(filepath.Join(dir, "jobs.log")); string(b) != tt.wantLog {
+				t.Errorf("jobs.log = %q, want %q", b, tt.wantLog)
+			}
+		})
+	}
+}
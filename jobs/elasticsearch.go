@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"encoding/json"
@@ -21,19 +23,131 @@ type Indexable interface {
 	ID() string
 }
 
-// Query represents search query
+// Query represents search query, a free-text query on Fields, a more like this query on Fields when Like is set,
+// a typed filter when Range or Term is set or a combination of queries when Bool is set.
+// Fuzziness enables typo tolerance on words, 'AUTO' or max edit distance.
+// Value uses simple query string syntax, unless Match is MatchWords or MatchPhrase and Value is plain text
 type Query struct {
-	Value    string
-	Fields   []string
-	Operator string
+	Value     string
+	Fields    []string
+	Operator  string
+	Fuzziness string
+	Match     string
+	Like      *Like
+	Range     *Range
+	Term      *Term
+	Bool      *Bool
 }
 
-// Sort represents search sort
+// Match values, how a plain text Value is matched
+const (
+	MatchWords  = "words"
+	MatchPhrase = "phrase"
+)
+
+// Bool represents a combination of queries, matches must match every Must, at least one Should when set and none of MustNot
+type Bool struct {
+	Must    []Query
+	Should  []Query
+	MustNot []Query
+}
+
+// Like represents documents of Type used as reference on a more like this query, the documents are not matched
+type Like struct {
+	Type string
+	IDs  []string
+}
+
+// Range represents a non scoring range filter on a numeric field, nil bounds are unbounded and bounds are inclusive unless excluded
+type Range struct {
+	Field      string
+	Min        *float64
+	Max        *float64
+	ExcludeMin bool
+	ExcludeMax bool
+}
+
+// Term represents a non scoring exact match filter on a keyword field, Path is set for fields of nested objects
+type Term struct {
+	Path  string
+	Field string
+	Value string
+}
+
+// BulkItemResult result of a single item on a bulk request, on the same order the items were sent
+type BulkItemResult struct {
+	ID     string
+	Result ItemResult
+	Error  *JobError
+}
+
+// Sort represents search sort on a field, use ScoreField to sort by relevance
 type Sort struct {
 	Field     string
 	Ascending bool
 }
 
+// ScoreField sort field for relevance
+const ScoreField = "_score"
+
+// Page represents search pagination, SearchAfter is used instead of From for deep paging
+type Page struct {
+	From        int
+	Size        int
+	SearchAfter []interface{}
+}
+
+// Facet represents an aggregation on search, terms of Field or range buckets of Field when Edges is set.
+// Path is set for fields of nested objects and Sum is a numeric field summed on each bucket
+type Facet struct {
+	Name  string
+	Field string
+	Size  int
+	Edges []float64
+	Path  string
+	Sum   string
+}
+
+// FacetBucket count of hits with a value or on a range, From is inclusive and To exclusive, Sum is only set for facets with Sum field
+type FacetBucket struct {
+	Key   string   `json:"key"`
+	From  *float64 `json:"from,omitempty"`
+	To    *float64 `json:"to,omitempty"`
+	Count int64    `json:"count"`
+	Sum   *float64 `json:"sum,omitempty"`
+}
+
+// Suggestion completion of a prefix, ranked by Score
+type Suggestion struct {
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
+
+// Highlight represents highlight options, matched terms on Fields are wrapped with PreTag and PostTag
+type Highlight struct {
+	Fields       []string
+	PreTag       string
+	PostTag      string
+	FragmentSize int
+	Fragments    int
+}
+
+// SearchHit represents a search hit with its metadata, Highlight has fragments by field when requested
+type SearchHit struct {
+	ID        string
+	Score     *float64
+	Source    json.RawMessage
+	Highlight map[string][]string
+}
+
+// SearchResult represents search hits, total of matches and facets by name, LastSort has the sort values of the last hit
+type SearchResult struct {
+	Total    int64
+	Hits     []SearchHit
+	LastSort []interface{}
+	Facets   map[string][]FacetBucket
+}
+
 // ElasticSearch impl of elastic search connection
 type ElasticSearch struct {
 	elasticClient *elastic.Client
@@ -72,35 +186,304 @@ func (e *ElasticSearch) client() *elastic.Client {
 	return e.elasticClient
 }
 
-// InitIndex create index if not exists
+// InitIndex creates a versioned index with mapping behind an alias with index name, when mapping changes creates a new version and migrates contents from the current one
 func (e *ElasticSearch) InitIndex(ctx context.Context, name, mapping string) error {
 	if e.client() == nil {
 		return NewElasticsearchConnectError("could not connect on elastic search")
 	}
-	if exists, err := e.client().IndexExists(name).Do(ctx); err != nil {
+
+	current, err := e.client().IndexGet(name).Do(ctx)
+	if err != nil && !elastic.IsNotFound(err) {
+		return NewElasticsearchAccessError(fmt.Sprintf("error checking if index exists on elasticsearch, message: %s", err.Error()))
+	}
+	target := versionedIndex(name, mapping)
+	if _, ok := current[target]; ok {
+		return nil
+	}
+
+	if exists, err := e.client().IndexExists(target).Do(ctx); err != nil {
 		return NewElasticsearchAccessError(fmt.Sprintf("error checking if index exists on elasticsearch, message: %s", err.Error()))
 	} else if !exists {
-		if _, err := e.client().CreateIndex(name).Body(mapping).Do(ctx); err != nil {
+		if _, err := e.client().CreateIndex(target).Body(mapping).Do(ctx); err != nil {
 			return NewElasticsearchAccessError(fmt.Sprintf("error initializing index on elasticsearch, message: %s", err.Error()))
 		}
 	}
+	if len(current) == 0 {
+		if _, err := e.client().Alias().Add(target, name).Do(ctx); err != nil {
+			return NewElasticsearchAccessError(fmt.Sprintf("error creating index alias on elasticsearch, message: %s", err.Error()))
+		}
+		return nil
+	}
+	for source := range current {
+		if err := e.migrateIndex(ctx, name, source, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrationScript fills jobs fields added after the jobs were indexed while copying them to a new index,
+// 'locations' is parsed from 'cidadeFormated' as parseLocations does, without regex as they are disabled on painless by default
+const migrationScript = `
+if (ctx._source.locations == null && ctx._source.cidadeFormated instanceof List) {
+	def locations = [];
+	for (def value : ctx._source.cidadeFormated) {
+		if (!(value instanceof String)) { continue; }
+		String v = value.trim();
+		int openings = 1;
+		int open = v.lastIndexOf('(');
+		if (v.endsWith(')') && open > 0) {
+			try { openings = Integer.parseInt(v.substring(open + 1, v.length() - 1).trim()); } catch (NumberFormatException e) { continue; }
+			v = v.substring(0, open).trim();
+		}
+		int dash = v.lastIndexOf('-');
+		if (dash < 1) { continue; }
+		String city = v.substring(0, dash).trim();
+		String state = v.substring(dash + 1).trim();
+		if (city.isEmpty() || state.length() != 2 || !Character.isLetter(state.charAt(0)) || !Character.isLetter(state.charAt(1))) { continue; }
+		locations.add(['city': city, 'state': state.toUpperCase(), 'openings': openings]);
+	}
+	if (!locations.isEmpty()) { ctx._source.locations = locations; }
+}`
+
+// migrateIndex copies contents from source to target and moves alias to target, source is removed after migration.
+// writes on source are rejected during migration, otherwise jobs written after the copy would be lost.
+// indexes created before versioning have the alias name and are removed on the same request creating the alias
+func (e *ElasticSearch) migrateIndex(ctx context.Context, alias, source, target string) error {
+	log.Printf("message=\"migrating index\" kind=elasticsearch alias=%s source=%s target=%s", alias, source, target)
+	if err := e.blockWrites(ctx, source, true); err != nil {
+		return err
+	}
+	response, err := e.client().Reindex().SourceIndex(source).DestinationIndex(target).Script(elastic.NewScript(migrationScript).Lang("painless")).ProceedOnVersionConflict().WaitForCompletion(true).Refresh("true").Do(ctx)
+	if err != nil {
+		e.unblockWrites(source)
+		return NewElasticsearchAccessError(fmt.Sprintf("error migrating index on elasticsearch, message: %s", err.Error()))
+	}
+
+	aliases := e.client().Alias()
+	if source == alias {
+		aliases.Add(target, alias).Action(aliasRemoveIndexAction(source))
+	} else {
+		aliases.Remove(source, alias).Add(target, alias)
+	}
+	if _, err := aliases.Do(ctx); err != nil {
+		e.unblockWrites(source)
+		return NewElasticsearchAccessError(fmt.Sprintf("error moving index alias on elasticsearch, message: %s", err.Error()))
+	}
+	if source != alias {
+		if _, err := e.client().DeleteIndex(source).Do(ctx); err != nil {
+			log.Printf("message=\"error removing migrated index\" kind=elasticsearch index=%s error=\"%s\"", source, err.Error())
+		}
+	}
+	log.Printf("message=\"index migrated\" kind=elasticsearch alias=%s source=%s target=%s total=%d", alias, source, target, response.Total)
 	return nil
 }
 
+// blockWrites rejects or accepts again writes on index, reads are not blocked
+func (e *ElasticSearch) blockWrites(ctx context.Context, index string, blocked bool) error {
+	if _, err := e.client().IndexPutSettings(index).BodyJson(map[string]interface{}{"index.blocks.write": blocked}).Do(ctx); err != nil {
+		return NewElasticsearchAccessError(fmt.Sprintf("error updating index write block on elasticsearch, message: %s", err.Error()))
+	}
+	return nil
+}
+
+// unblockWrites accepts writes again on index of a failed migration, even when the migration context is done
+func (e *ElasticSearch) unblockWrites(index string) {
+	if err := e.blockWrites(context.Background(), index, false); err != nil {
+		log.Printf("message=\"error unblocking writes after failed migration\" kind=elasticsearch index=%s error=\"%s\"", index, err.Error())
+	}
+}
+
+// aliasRemoveIndexAction alias action removing an index, applied atomically with the other actions of the request
+type aliasRemoveIndexAction string
+
+// Source body of the action
+func (a aliasRemoveIndexAction) Source() (interface{}, error) {
+	return map[string]interface{}{"remove_index": map[string]interface{}{"index": string(a)}}, nil
+}
+
+// versionedIndex index name with a version from mapping content
+func versionedIndex(name, mapping string) string {
+	return name + "_" + hash(mapping)[:8]
+}
+
 // Add add content do index
 func (e *ElasticSearch) Add(ctx context.Context, index string, content Indexable) error {
 	if e.client() == nil {
 		return NewElasticsearchConnectError("could not connect on elastic search")
 	}
 
-	if _, err := e.client().Index().Index(index).Type(strings.ToLower(reflect.TypeOf(content).Name())).Id(content.ID()).BodyJson(content).Do(ctx); err != nil {
+	if _, err := e.client().Index().Index(index).Type(indexType(content)).Id(content.ID()).BodyJson(content).Do(ctx); err != nil {
 		return NewElasticsearchAccessError(fmt.Sprintf("error indexing on elasticsearch, message: %s", err.Error()))
 	}
 	return nil
 }
 
+// BulkAdd add all contents to index using a single bulk request, returns the result of each item
+func (e *ElasticSearch) BulkAdd(ctx context.Context, index string, items []Indexable) ([]BulkItemResult, error) {
+	if len(items) < 1 {
+		return nil, NewInvalidRequestError("items is empty")
+	}
+	if e.client() == nil {
+		return nil, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	bulk := e.client().Bulk().Index(index)
+	for _, item := range items {
+		bulk.Add(elastic.NewBulkIndexRequest().Type(indexType(item)).Id(item.ID()).Doc(item))
+	}
+	response, err := bulk.Do(ctx)
+	if err != nil {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error bulk indexing on elasticsearch, message: %s", err.Error()))
+	}
+	if len(response.Items) != len(items) {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error bulk indexing on elasticsearch, expected %d items on response, got %d", len(items), len(response.Items)))
+	}
+
+	result := make([]BulkItemResult, len(items))
+	for i, item := range response.Items {
+		result[i] = toBulkItemResult(items[i].ID(), "index", item["index"])
+	}
+	return result, nil
+}
+
+// Delete remove content from index by id
+func (e *ElasticSearch) Delete(ctx context.Context, index, typ, id string) error {
+	if e.client() == nil {
+		return NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	if _, err := e.client().Delete().Index(index).Type(typ).Id(id).Do(ctx); err != nil {
+		if elastic.IsNotFound(err) {
+			return NewNotFoundError(fmt.Sprintf("id %s not found", id))
+		}
+		return NewElasticsearchAccessError(fmt.Sprintf("error deleting on elasticsearch, message: %s", err.Error()))
+	}
+	return nil
+}
+
+// BulkDelete remove contents from index by id using a single bulk request, returns the result of each id
+func (e *ElasticSearch) BulkDelete(ctx context.Context, index, typ string, ids []string) ([]BulkItemResult, error) {
+	if len(ids) < 1 {
+		return nil, NewInvalidRequestError("ids is empty")
+	}
+	if e.client() == nil {
+		return nil, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	bulk := e.client().Bulk().Index(index).Type(typ)
+	for _, id := range ids {
+		bulk.Add(elastic.NewBulkDeleteRequest().Id(id))
+	}
+	response, err := bulk.Do(ctx)
+	if err != nil {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error bulk deleting on elasticsearch, message: %s", err.Error()))
+	}
+	if len(response.Items) != len(ids) {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error bulk deleting on elasticsearch, expected %d items on response, got %d", len(ids), len(response.Items)))
+	}
+
+	result := make([]BulkItemResult, len(ids))
+	for i, item := range response.Items {
+		result[i] = toBulkItemResult(ids[i], "delete", item["delete"])
+	}
+	return result, nil
+}
+
+// Count count contents on index matching queries
+func (e *ElasticSearch) Count(ctx context.Context, index string, queries ...Query) (int64, error) {
+	if len(queries) < 1 {
+		return 0, NewInvalidRequestError("queries is empty")
+	}
+	if e.client() == nil {
+		return 0, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	count, err := e.client().Count(index).Query(createElasticCompoundQuery(queries...)).Do(ctx)
+	if err != nil {
+		return 0, NewElasticsearchAccessError(fmt.Sprintf("error counting on elasticsearch, message: %s", err.Error()))
+	}
+	return count, nil
+}
+
+// DeleteByQuery remove all contents on index matching queries
+func (e *ElasticSearch) DeleteByQuery(ctx context.Context, index string, queries ...Query) (*DeleteByQueryResult, error) {
+	if len(queries) < 1 {
+		return nil, NewInvalidRequestError("queries is empty")
+	}
+	if e.client() == nil {
+		return nil, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	response, err := e.client().DeleteByQuery(index).Query(createElasticCompoundQuery(queries...)).ProceedOnVersionConflict().Do(ctx)
+	if err != nil {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error deleting by query on elasticsearch, message: %s", err.Error()))
+	}
+	if len(response.Failures) > 0 {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error deleting by query on elasticsearch, %d failures, deleted %d of %d", len(response.Failures), response.Deleted, response.Total))
+	}
+	return &DeleteByQueryResult{Matched: response.Total, Deleted: response.Deleted}, nil
+}
+
+func toBulkItemResult(id, action string, item *elastic.BulkResponseItem) BulkItemResult {
+	switch {
+	case item == nil:
+		return BulkItemResult{ID: id, Result: ItemFailed, Error: NewElasticsearchAccessError("missing item on bulk response")}
+	case action == "delete" && item.Status == http.StatusNotFound:
+		return BulkItemResult{ID: id, Result: ItemFailed, Error: NewNotFoundError(fmt.Sprintf("id %s not found", id))}
+	case action == "delete" && item.Status == http.StatusOK:
+		return BulkItemResult{ID: id, Result: ItemDeleted}
+	case item.Status == http.StatusCreated:
+		return BulkItemResult{ID: id, Result: ItemCreated}
+	case item.Status == http.StatusOK:
+		return BulkItemResult{ID: id, Result: ItemUpdated}
+	default:
+		return BulkItemResult{ID: id, Result: ItemFailed, Error: newBulkItemError(item)}
+	}
+}
+
+// newBulkItemError maps a failed bulk item to JobError according with status and elasticsearch error type
+func newBulkItemError(item *elastic.BulkResponseItem) *JobError {
+	msg := fmt.Sprintf("error indexing on elasticsearch, status: %d", item.Status)
+	errType := ""
+	if item.Error != nil {
+		errType = item.Error.Type
+		msg = fmt.Sprintf("error indexing on elasticsearch, status: %d, type: %s, message: %s", item.Status, item.Error.Type, item.Error.Reason)
+	}
+
+	switch {
+	case item.Status == http.StatusBadRequest && strings.Contains(errType, "parsing"):
+		return NewParserError(msg)
+	case item.Status == http.StatusBadRequest, item.Status == http.StatusConflict:
+		return NewInvalidRequestError(msg)
+	case item.Status == http.StatusNotFound:
+		return NewNotFoundError(msg)
+	default:
+		return NewElasticsearchAccessError(msg)
+	}
+}
+
+// Get get content from index by id
+func (e *ElasticSearch) Get(ctx context.Context, index, id string) (json.RawMessage, error) {
+	if e.client() == nil {
+		return nil, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	result, err := e.client().Get().Index(index).Id(id).Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return nil, NewNotFoundError(fmt.Sprintf("id %s not found", id))
+		}
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error getting from elasticsearch, message: %s", err.Error()))
+	}
+	if !result.Found || result.Source == nil {
+		return nil, NewNotFoundError(fmt.Sprintf("id %s not found", id))
+	}
+	return *result.Source, nil
+}
+
 // Search search content on index
-func (e *ElasticSearch) Search(ctx context.Context, index string, sort *Sort, queries ...Query) ([]json.RawMessage, error) {
+func (e *ElasticSearch) Search(ctx context.Context, index string, sorts []Sort, page *Page, facets []Facet, highlight *Highlight, queries ...Query) (*SearchResult, error) {
 	if len(queries) < 1 {
 		return nil, NewInvalidRequestError("queries is empty")
 	}
@@ -108,35 +491,264 @@ func (e *ElasticSearch) Search(ctx context.Context, index string, sort *Sort, qu
 		return nil, NewElasticsearchConnectError("could not connect on elastic search")
 	}
 
-	s := e.client().Search(index).Query(createElasticCompoundQuery(queries...))
-	if sort != nil {
-		s.Sort(sort.Field, sort.Ascending)
+	body, err := createSearchBody(sorts, page, facets, highlight, queries...)
+	if err != nil {
+		return nil, NewInvalidRequestError(fmt.Sprintf("error creating search, message: %s", err.Error()))
 	}
-	searchResult, err := s.Do(ctx)
+	searchResult, err := e.client().Search(index).Source(body).Do(ctx)
 	if err != nil {
 		return nil, NewElasticsearchAccessError(fmt.Sprintf("error searching on elasticsearch, message: %s", err.Error()))
 	}
 
-	var result []json.RawMessage
+	result := &SearchResult{Total: searchResult.TotalHits(), Facets: toFacetBuckets(searchResult.Aggregations, facets)}
+	if searchResult.Hits == nil {
+		return result, nil
+	}
 	for _, hit := range searchResult.Hits.Hits {
-		result = append(result, *hit.Source)
+		if hit.Source == nil {
+			continue
+		}
+		result.Hits = append(result.Hits, SearchHit{ID: hit.Id, Score: hit.Score, Source: *hit.Source, Highlight: hit.Highlight})
+		result.LastSort = hit.Sort
+	}
+	return result, nil
+}
+
+// Suggest completes prefix using the completion field, returns the suggestions ranked by score
+func (e *ElasticSearch) Suggest(ctx context.Context, index, field, prefix string, size int) ([]Suggestion, error) {
+	if prefix == "" {
+		return nil, NewInvalidRequestError("prefix is empty")
+	}
+	if e.client() == nil {
+		return nil, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	source := elastic.NewSearchSource().Size(0).FetchSource(false).Suggester(elastic.NewCompletionSuggester("suggestions").Text(prefix).Field(field).Size(size))
+	searchResult, err := e.client().Search(index).SearchSource(source).Do(ctx)
+	if err != nil {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error suggesting on elasticsearch, message: %s", err.Error()))
+	}
+
+	suggestions := make([]Suggestion, 0)
+	for _, suggestion := range searchResult.Suggest["suggestions"] {
+		for _, option := range suggestion.Options {
+			suggestions = append(suggestions, Suggestion{Text: option.Text, Score: option.Score})
+		}
+	}
+	return suggestions, nil
+}
+
+// percolator type and field of the queries stored on percolator indexes, at most maxPercolateMatches queries are returned for each document
+const (
+	percolatorType      = "percolator"
+	percolatorField     = "query"
+	maxPercolateMatches = 1000
+)
+
+// AddPercolator stores queries with id on a percolator index, documents matching the queries are found by Percolate
+func (e *ElasticSearch) AddPercolator(ctx context.Context, index, id string, queries ...Query) error {
+	if e.client() == nil {
+		return NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	source, err := createElasticCompoundQuery(queries...).Source()
+	if err != nil {
+		return NewInvalidRequestError(fmt.Sprintf("error creating percolator query, message: %s", err.Error()))
+	}
+	if _, err := e.client().Index().Index(index).Type(percolatorType).Id(id).BodyJson(map[string]interface{}{percolatorField: source}).Do(ctx); err != nil {
+		return NewElasticsearchAccessError(fmt.Sprintf("error indexing percolator on elasticsearch, message: %s", err.Error()))
+	}
+	return nil
+}
+
+// Percolate finds the ids of the stored queries matching each document using a single multi search request, on the same order the documents were sent
+func (e *ElasticSearch) Percolate(ctx context.Context, index string, docs []Indexable) ([][]string, error) {
+	if len(docs) < 1 {
+		return nil, NewInvalidRequestError("docs is empty")
+	}
+	if e.client() == nil {
+		return nil, NewElasticsearchConnectError("could not connect on elastic search")
+	}
+
+	search := e.client().MultiSearch()
+	for _, doc := range docs {
+		query := elastic.NewPercolatorQuery().Field(percolatorField).DocumentType(indexType(doc)).Document(doc)
+		source, err := elastic.NewSearchSource().Query(query).Size(maxPercolateMatches).FetchSource(false).Source()
+		if err != nil {
+			return nil, NewInvalidRequestError(fmt.Sprintf("error creating percolate search, message: %s", err.Error()))
+		}
+		search.Add(elastic.NewSearchRequest().Index(index).Type(percolatorType).Source(source))
+	}
+	response, err := search.Do(ctx)
+	if err != nil {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error percolating on elasticsearch, message: %s", err.Error()))
+	}
+	if len(response.Responses) != len(docs) {
+		return nil, NewElasticsearchAccessError(fmt.Sprintf("error percolating on elasticsearch, expected %d responses, got %d", len(docs), len(response.Responses)))
+	}
+
+	result := make([][]string, len(docs))
+	for i, r := range response.Responses {
+		if r == nil {
+			continue
+		}
+		if r.Error != nil {
+			return nil, NewElasticsearchAccessError(fmt.Sprintf("error percolating on elasticsearch, message: %s", r.Error.Reason))
+		}
+		if r.TotalHits() > maxPercolateMatches {
+			log.Printf("message=\"percolate matches truncated\" kind=elasticsearch index=%s id=%s total=%d", index, docs[i].ID(), r.TotalHits())
+		}
+		if r.Hits == nil {
+			continue
+		}
+		for _, hit := range r.Hits.Hits {
+			result[i] = append(result[i], hit.Id)
+		}
 	}
 	return result, nil
 }
 
+// createSearchBody creates search source, the vendored client has no search_after support so it is added on the body
+func createSearchBody(sorts []Sort, page *Page, facets []Facet, highlight *Highlight, queries ...Query) (interface{}, error) {
+	source := elastic.NewSearchSource().Query(createElasticCompoundQuery(queries...))
+	for _, f := range facets {
+		source.Aggregation(f.Name, createElasticAggregation(f))
+	}
+	for _, sort := range sorts {
+		source.Sort(sort.Field, sort.Ascending)
+	}
+	if len(sorts) > 0 {
+		source.Sort("_uid", true).TrackScores(true) //tie-breaker, required by search_after, scores are only computed by default when sorting by score
+	}
+	if highlight != nil {
+		source.Highlight(createElasticHighlight(highlight))
+	}
+	if page != nil {
+		if len(page.SearchAfter) == 0 {
+			source.From(page.From)
+		}
+		source.Size(page.Size)
+	}
+
+	body, err := source.Source()
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && len(page.SearchAfter) > 0 {
+		body.(map[string]interface{})["search_after"] = page.SearchAfter
+	}
+	return body, nil
+}
+
+func createElasticHighlight(h *Highlight) *elastic.Highlight {
+	highlight := elastic.NewHighlight().PreTags(h.PreTag).PostTags(h.PostTag).FragmentSize(h.FragmentSize).NumOfFragments(h.Fragments)
+	for _, field := range h.Fields {
+		highlight.Field(field)
+	}
+	return highlight
+}
+
+// facet sub aggregations names
+const (
+	facetHitsAggregation = "hits"
+	facetSumAggregation  = "sum"
+)
+
+// createElasticAggregation creates terms or range aggregation, nested facets count parent hits using a reverse nested aggregation
+func createElasticAggregation(f Facet) elastic.Aggregation {
+	if len(f.Edges) == 0 {
+		terms := elastic.NewTermsAggregation().Field(f.Field).Size(f.Size)
+		if f.Sum != "" {
+			terms.SubAggregation(facetSumAggregation, elastic.NewSumAggregation().Field(f.Sum))
+		}
+		if f.Path == "" {
+			return terms
+		}
+		terms.SubAggregation(facetHitsAggregation, elastic.NewReverseNestedAggregation())
+		return elastic.NewNestedAggregation().Path(f.Path).SubAggregation(f.Name, terms)
+	}
+
+	agg := elastic.NewRangeAggregation().Field(f.Field).AddUnboundedFrom(f.Edges[0])
+	for i := 1; i < len(f.Edges); i++ {
+		agg.AddRange(f.Edges[i-1], f.Edges[i])
+	}
+	return agg.AddUnboundedTo(f.Edges[len(f.Edges)-1])
+}
+
+func toFacetBuckets(aggs elastic.Aggregations, facets []Facet) map[string][]FacetBucket {
+	if len(facets) == 0 {
+		return nil
+	}
+
+	result := make(map[string][]FacetBucket)
+	for _, f := range facets {
+		buckets := make([]FacetBucket, 0)
+		if len(f.Edges) == 0 {
+			termsAggs := aggs
+			if f.Path != "" {
+				if nested, found := aggs.Nested(f.Name); found {
+					termsAggs = nested.Aggregations
+				}
+			}
+			if terms, found := termsAggs.Terms(f.Name); found {
+				for _, b := range terms.Buckets {
+					bucket := FacetBucket{Key: fmt.Sprint(b.Key), Count: b.DocCount}
+					if hits, found := b.ReverseNested(facetHitsAggregation); found {
+						bucket.Count = hits.DocCount
+					}
+					if sum, found := b.Sum(facetSumAggregation); found {
+						bucket.Sum = sum.Value
+					}
+					buckets = append(buckets, bucket)
+				}
+			}
+		} else if ranges, found := aggs.Range(f.Name); found {
+			for _, b := range ranges.Buckets {
+				buckets = append(buckets, FacetBucket{Key: b.Key, From: b.From, To: b.To, Count: b.DocCount})
+			}
+		}
+		result[f.Name] = buckets
+	}
+	return result
+}
+
+func indexType(content Indexable) string {
+	return strings.ToLower(reflect.TypeOf(content).Name())
+}
+
 func createElasticCompoundQuery(queries ...Query) elastic.Query {
-	if len(queries) == 1 {
+	if len(queries) == 1 && queries[0].Range == nil && queries[0].Term == nil {
 		return createElasticQuery(queries[0])
 	}
 
 	query := elastic.NewBoolQuery()
 	for _, q := range queries {
+		if q.Range != nil {
+			query.Filter(createElasticRangeQuery(*q.Range))
+			continue
+		}
+		if q.Term != nil {
+			query.Filter(createElasticTermQuery(*q.Term))
+			continue
+		}
 		query.Must(createElasticQuery(q))
 	}
 	return query
 }
 
 func createElasticQuery(q Query) elastic.Query {
+	if q.Bool != nil {
+		return createElasticBoolQuery(*q.Bool)
+	}
+	if q.Like != nil {
+		return createElasticMoreLikeThisQuery(q)
+	}
+	if q.Match != "" {
+		return createElasticMatchQuery(q)
+	}
+	if q.Fuzziness != "" {
+		return createElasticFuzzyQuery(q)
+	}
 	query := elastic.NewSimpleQueryStringQuery(q.Value)
 	query.DefaultOperator(q.Operator)
 	query.AnalyzeWildcard(true)
@@ -145,3 +757,158 @@ func createElasticQuery(q Query) elastic.Query {
 	}
 	return query
 }
+
+// createElasticMatchQuery matches plain text, without operators, words with Operator and Fuzziness or a phrase
+func createElasticMatchQuery(q Query) elastic.Query {
+	query := elastic.NewMultiMatchQuery(q.Value, q.Fields...)
+	if q.Match == MatchPhrase {
+		return query.Type("phrase")
+	}
+	query.Operator(q.Operator)
+	if q.Fuzziness != "" {
+		query.Fuzziness(q.Fuzziness)
+	}
+	return query
+}
+
+// createElasticBoolQuery filters on Must ranges and terms, Should requires at least one match even with Must clauses
+func createElasticBoolQuery(b Bool) elastic.Query {
+	query := elastic.NewBoolQuery()
+	for _, q := range b.Must {
+		if q.Range != nil || q.Term != nil {
+			query.Filter(createElasticClause(q))
+		} else {
+			query.Must(createElasticClause(q))
+		}
+	}
+	for _, q := range b.Should {
+		query.Should(createElasticClause(q))
+	}
+	if len(b.Should) > 0 {
+		query.MinimumNumberShouldMatch(1)
+	}
+	for _, q := range b.MustNot {
+		query.MustNot(createElasticClause(q))
+	}
+	return query
+}
+
+func createElasticClause(q Query) elastic.Query {
+	switch {
+	case q.Range != nil:
+		return createElasticRangeQuery(*q.Range)
+	case q.Term != nil:
+		return createElasticTermQuery(*q.Term)
+	}
+	return createElasticQuery(q)
+}
+
+// createElasticMoreLikeThisQuery uses every term of the documents, job texts are too short for the default frequencies
+func createElasticMoreLikeThisQuery(q Query) elastic.Query {
+	query := elastic.NewMoreLikeThisQuery().Field(q.Fields...).MinTermFreq(1).MinDocFreq(1).Include(false)
+	for _, id := range q.Like.IDs {
+		query.LikeItems(elastic.NewMoreLikeThisQueryItem().Type(q.Like.Type).Id(id))
+	}
+	return query
+}
+
+var queryTerms = regexp.MustCompile(`-?"[^"]*"|[|+]|[^\s"|+]+`)
+
+// createElasticFuzzyQuery keeps simple query string semantics with fuzzy words, quoted phrases are exact and words with trailing '*' are prefixes.
+// '|' splits alternative groups, on each group '+' requires every word and words starting with '-' must not match
+func createElasticFuzzyQuery(q Query) elastic.Query {
+	var groups []elastic.Query
+	var terms []string
+	for _, term := range append(queryTerms.FindAllString(q.Value, -1), "|") {
+		if term != "|" {
+			terms = append(terms, term)
+			continue
+		}
+		if len(terms) > 0 {
+			groups = append(groups, createElasticFuzzyGroup(q, terms))
+		}
+		terms = nil
+	}
+	if len(groups) == 1 {
+		return groups[0]
+	}
+
+	query := elastic.NewBoolQuery()
+	for _, group := range groups {
+		query.Should(group)
+	}
+	if len(groups) > 0 {
+		query.MinimumNumberShouldMatch(1)
+	}
+	return query
+}
+
+// createElasticFuzzyGroup words between '|', required with 'and' operator or '+', otherwise at least one must match
+func createElasticFuzzyGroup(q Query, terms []string) elastic.Query {
+	required := q.Operator == "and"
+	var clauses, excluded []elastic.Query
+	for _, term := range terms {
+		switch {
+		case term == "+":
+			required = true
+		case len(term) > 1 && strings.HasPrefix(term, "-"):
+			excluded = append(excluded, createElasticFuzzyTerm(q, term[1:]))
+		default:
+			clauses = append(clauses, createElasticFuzzyTerm(q, term))
+		}
+	}
+	if len(clauses) == 1 && len(excluded) == 0 {
+		return clauses[0]
+	}
+
+	query := elastic.NewBoolQuery()
+	for _, clause := range clauses {
+		if required {
+			query.Must(clause)
+		} else {
+			query.Should(clause)
+		}
+	}
+	if !required && len(clauses) > 0 {
+		query.MinimumNumberShouldMatch(1)
+	}
+	for _, clause := range excluded {
+		query.MustNot(clause)
+	}
+	return query
+}
+
+func createElasticFuzzyTerm(q Query, term string) elastic.Query {
+	switch {
+	case strings.HasPrefix(term, `"`):
+		return elastic.NewMultiMatchQuery(strings.Trim(term, `"`), q.Fields...).Type("phrase")
+	case strings.HasSuffix(term, "*"):
+		return createElasticQuery(Query{Value: term, Fields: q.Fields, Operator: q.Operator})
+	}
+	return elastic.NewMultiMatchQuery(term, q.Fields...).Fuzziness(q.Fuzziness)
+}
+
+func createElasticTermQuery(t Term) elastic.Query {
+	query := elastic.NewTermQuery(t.Field, t.Value)
+	if t.Path != "" {
+		return elastic.NewNestedQuery(t.Path, query)
+	}
+	return query
+}
+
+func createElasticRangeQuery(r Range) elastic.Query {
+	query := elastic.NewRangeQuery(r.Field)
+	switch {
+	case r.Min != nil && r.ExcludeMin:
+		query.Gt(*r.Min)
+	case r.Min != nil:
+		query.Gte(*r.Min)
+	}
+	switch {
+	case r.Max != nil && r.ExcludeMax:
+		query.Lt(*r.Max)
+	case r.Max != nil:
+		query.Lte(*r.Max)
+	}
+	return query
+}
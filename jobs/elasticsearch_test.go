@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -27,10 +28,14 @@ func TestElasticSearch_InitIndex(t *testing.T) {
 		wantErr bool
 	}{
 		{"no client error", &ElasticSearch{}, args{context.TODO(), "jobs", "{}"}, true},
-		{"elastic index not found error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"HEAD  /jobs": {nil, errors.New("index not found")}})}, args{context.TODO(), "jobs", "{}"}, true},
-		{"index exists", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"HEAD /jobs": {newResponse(200, "{}"), nil}})}, args{context.TODO(), "jobs", "{}"}, false},
-		{"error creating index", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"HEAD /jobs": {newResponse(404, "{}"), nil}, "PUT /jobs": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", "{}"}, true},
-		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"HEAD /jobs": {newResponse(404, "{}"), nil}, "PUT /jobs": {newResponse(200, "{}"), nil}})}, args{context.TODO(), "jobs", "{}"}, false},
+		{"elastic index error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", "{}"}, true},
+		{"index up to date", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(200, `{"`+versionedIndex("jobs", "{}")+`":{"aliases":{"jobs":{}}}}`), nil}})}, args{context.TODO(), "jobs", "{}"}, false},
+		{"error creating index", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(404, "{}"), nil}, "HEAD /jobs_": {newResponse(404, "{}"), nil}, "PUT /jobs_": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", "{}"}, true},
+		{"error creating alias", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(404, "{}"), nil}, "HEAD /jobs_": {newResponse(404, "{}"), nil}, "PUT /jobs_": {newResponse(200, "{}"), nil}, "POST /_aliases": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", "{}"}, true},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(404, "{}"), nil}, "HEAD /jobs_": {newResponse(404, "{}"), nil}, "PUT /jobs_": {newResponse(200, "{}"), nil}, "POST /_aliases": {newResponse(200, `{"acknowledged":true}`), nil}})}, args{context.TODO(), "jobs", "{}"}, false},
+		{"error migrating index", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(200, `{"jobs":{"aliases":{}}}`), nil}, "HEAD /jobs_": {newResponse(200, "{}"), nil}, "PUT /jobs/_settings": {newResponse(200, `{"acknowledged":true}`), nil}, "POST /_reindex": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", "{}"}, true},
+		{"migrate index without alias", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(200, `{"jobs":{"aliases":{}}}`), nil}, "HEAD /jobs_": {newResponse(404, "{}"), nil}, "PUT /jobs_": {newResponse(200, "{}"), nil}, "PUT /jobs/_settings": {newResponse(200, `{"acknowledged":true}`), nil}, "POST /_reindex": {newResponse(200, `{"total":2,"created":2}`), nil}, "POST /_aliases": {newResponse(200, `{"acknowledged":true}`), nil}})}, args{context.TODO(), "jobs", "{}"}, false},
+		{"migrate versioned index", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs": {newResponse(200, `{"jobs_00000000":{"aliases":{"jobs":{}}}}`), nil}, "HEAD /jobs_": {newResponse(404, "{}"), nil}, "PUT /jobs_": {newResponse(200, "{}"), nil}, "PUT /jobs_00000000/_settings": {newResponse(200, `{"acknowledged":true}`), nil}, "POST /_reindex": {newResponse(200, `{"total":2,"created":2}`), nil}, "POST /_aliases": {newResponse(200, `{"acknowledged":true}`), nil}, "DELETE /jobs_00000000": {newResponse(200, `{"acknowledged":true}`), nil}})}, args{context.TODO(), "jobs", "{}"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -41,6 +46,55 @@ func TestElasticSearch_InitIndex(t *testing.T) {
 	}
 }
 
+func TestElasticSearch_migrateIndex(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		failOn       string
+		wantErr      bool
+		wantRequests []string
+	}{
+		{"index without alias", "jobs", "", false, []string{`PUT /jobs/_settings {"index.blocks.write":true}`, "POST /_reindex", `POST /_aliases {"actions":[{"add":{"alias":"jobs","index":"jobs_new"}},{"remove_index":{"index":"jobs"}}]}`}},
+		{"versioned index", "jobs_old", "", false, []string{`PUT /jobs_old/_settings {"index.blocks.write":true}`, "POST /_reindex", `POST /_aliases {"actions":[{"remove":{"alias":"jobs","index":"jobs_old"}},{"add":{"alias":"jobs","index":"jobs_new"}}]}`, "DELETE /jobs_old"}},
+		{"error blocking writes", "jobs_old", "PUT", true, []string{`PUT /jobs_old/_settings {"index.blocks.write":true}`}},
+		{"error reindexing unblocks writes", "jobs_old", "POST /_reindex", true, []string{`PUT /jobs_old/_settings {"index.blocks.write":true}`, "POST /_reindex", `PUT /jobs_old/_settings {"index.blocks.write":false}`}},
+		{"error moving alias unblocks writes", "jobs", "POST /_aliases", true, []string{`PUT /jobs/_settings {"index.blocks.write":true}`, "POST /_reindex", `POST /_aliases {"actions":[{"add":{"alias":"jobs","index":"jobs_new"}},{"remove_index":{"index":"jobs"}}]}`, `PUT /jobs/_settings {"index.blocks.write":false}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			var reindex string
+			fn := func(r *http.Request) (*http.Response, error) {
+				request := r.Method + " " + r.URL.Path
+				if r.Method != "DELETE" {
+					body, _ := ioutil.ReadAll(r.Body)
+					if strings.HasSuffix(r.URL.Path, "_reindex") {
+						reindex = string(body)
+					} else {
+						request += " " + string(body)
+					}
+				}
+				requests = append(requests, request)
+				if tt.failOn != "" && strings.HasPrefix(request, tt.failOn) {
+					return newResponse(500, `{"error":{"type":"exception","reason":"error on elastic"}}`), nil
+				}
+				return newResponse(200, `{"acknowledged":true,"total":2}`), nil
+			}
+			client, _ := elastic.NewSimpleClient(elastic.SetHttpClient(&http.Client{Transport: &mockRoundTripper{fn: fn}}), elastic.SetMaxRetries(0))
+			e := &ElasticSearch{elasticClient: client}
+			if err := e.migrateIndex(context.TODO(), "jobs", tt.source, "jobs_new"); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearch.migrateIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("ElasticSearch.migrateIndex() requests = %v, want %v", requests, tt.wantRequests)
+			}
+			if reindex != "" && !strings.Contains(reindex, `"lang":"painless"`) {
+				t.Errorf("ElasticSearch.migrateIndex() reindex = %v, want migration script", reindex)
+			}
+		})
+	}
+}
+
 func TestElasticSearch_Add(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -66,29 +120,324 @@ func TestElasticSearch_Add(t *testing.T) {
 	}
 }
 
+func TestElasticSearch_BulkAdd(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		index string
+		items []Indexable
+	}
+	tests := []struct {
+		name    string
+		e       *ElasticSearch
+		args    args
+		want    []BulkItemResult
+		wantErr bool
+	}{
+		{"no items error", &ElasticSearch{}, args{context.TODO(), "jobs", nil}, nil, true},
+		{"no client error", &ElasticSearch{}, args{context.TODO(), "jobs", []Indexable{Job{}}}, nil, true},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_bulk": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", []Indexable{Job{}}}, nil, true},
+		{"missing items error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_bulk": {newResponse(200, successBulkResponseBody()), nil}})}, args{context.TODO(), "jobs", []Indexable{Job{Title: "a"}, Job{Title: "b"}}}, nil, true},
+		{"item failed", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_bulk": {newResponse(200, failedBulkResponseBody()), nil}})}, args{context.TODO(), "jobs", []Indexable{Job{Title: "a"}}}, []BulkItemResult{{ID: Job{Title: "a"}.ID(), Result: ItemFailed, Error: NewParserError("error indexing on elasticsearch, status: 400, type: mapper_parsing_exception, message: failed to parse [salario]")}}, false},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_bulk": {newResponse(200, successBulkResponseBody()), nil}})}, args{context.TODO(), "jobs", []Indexable{Job{Title: "a"}}}, []BulkItemResult{{ID: Job{Title: "a"}.ID(), Result: ItemCreated}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.BulkAdd(tt.args.ctx, tt.args.index, tt.args.items)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearch.BulkAdd() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearch.BulkAdd() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		e        *ElasticSearch
+		wantCode string
+	}{
+		{"no client error", &ElasticSearch{}, JOB2001},
+		{"not found error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"DELETE /jobs/job/id1": {newResponse(404, `{"found":false}`), nil}})}, JOB1002},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"DELETE /jobs/job/id1": {nil, errors.New("error on elastic")}})}, JOB2002},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"DELETE /jobs/job/id1": {newResponse(200, `{"found":true,"_id":"id1"}`), nil}})}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.e.Delete(context.TODO(), "jobs", "job", "id1")
+			if (err == nil) != (tt.wantCode == "") {
+				t.Errorf("ElasticSearch.Delete() error = %v, want code %v", err, tt.wantCode)
+				return
+			}
+			if err != nil && err.(*JobError).ErrCode != tt.wantCode {
+				t.Errorf("ElasticSearch.Delete() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_BulkDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       *ElasticSearch
+		ids     []string
+		want    []BulkItemResult
+		wantErr bool
+	}{
+		{"no ids error", &ElasticSearch{}, nil, nil, true},
+		{"no client error", &ElasticSearch{}, []string{"id1"}, nil, true},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/job/_bulk": {nil, errors.New("error on elastic")}})}, []string{"id1"}, nil, true},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/job/_bulk": {newResponse(200, bulkDeleteResponseBody()), nil}})}, []string{"id1", "id2"}, []BulkItemResult{{ID: "id1", Result: ItemDeleted}, {ID: "id2", Result: ItemFailed, Error: NewNotFoundError("id id2 not found")}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.BulkDelete(context.TODO(), "jobs", "job", tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearch.BulkDelete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearch.BulkDelete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_Count(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       *ElasticSearch
+		queries []Query
+		want    int64
+		wantErr bool
+	}{
+		{"no query error", &ElasticSearch{}, nil, 0, true},
+		{"no client error", &ElasticSearch{}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, 0, true},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_count": {nil, errors.New("error on elastic")}})}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, 0, true},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_count": {newResponse(200, `{"count":42}`), nil}})}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, 42, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.Count(context.TODO(), "jobs", tt.queries...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearch.Count() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ElasticSearch.Count() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_DeleteByQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       *ElasticSearch
+		queries []Query
+		want    *DeleteByQueryResult
+		wantErr bool
+	}{
+		{"no query error", &ElasticSearch{}, nil, nil, true},
+		{"no client error", &ElasticSearch{}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, nil, true},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_delete_by_query": {nil, errors.New("error on elastic")}})}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, nil, true},
+		{"failures error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_delete_by_query": {newResponse(200, `{"total":2,"deleted":1,"failures":[{"index":"jobs","type":"job","id":"id1","status":500}]}`), nil}})}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, nil, true},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_delete_by_query": {newResponse(200, `{"total":2,"deleted":2,"failures":[]}`), nil}})}, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, &DeleteByQueryResult{Matched: 2, Deleted: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.DeleteByQuery(context.TODO(), "jobs", tt.queries...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearch.DeleteByQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearch.DeleteByQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toBulkItemResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		item     *elastic.BulkResponseItem
+		want     ItemResult
+		wantCode string
+	}{
+		{"missing item", "index", nil, ItemFailed, JOB2002},
+		{"created", "index", &elastic.BulkResponseItem{Status: 201}, ItemCreated, ""},
+		{"updated", "index", &elastic.BulkResponseItem{Status: 200}, ItemUpdated, ""},
+		{"deleted", "delete", &elastic.BulkResponseItem{Status: 200, Found: true}, ItemDeleted, ""},
+		{"delete not found", "delete", &elastic.BulkResponseItem{Status: 404}, ItemFailed, JOB1002},
+		{"parser error", "index", &elastic.BulkResponseItem{Status: 400, Error: &elastic.ErrorDetails{Type: "mapper_parsing_exception"}}, ItemFailed, JOB1003},
+		{"invalid error", "index", &elastic.BulkResponseItem{Status: 400, Error: &elastic.ErrorDetails{Type: "illegal_argument_exception"}}, ItemFailed, JOB1001},
+		{"conflict error", "index", &elastic.BulkResponseItem{Status: 409, Error: &elastic.ErrorDetails{Type: "version_conflict_engine_exception"}}, ItemFailed, JOB1001},
+		{"not found error", "index", &elastic.BulkResponseItem{Status: 404, Error: &elastic.ErrorDetails{Type: "index_not_found_exception"}}, ItemFailed, JOB1002},
+		{"rejected error", "index", &elastic.BulkResponseItem{Status: 429, Error: &elastic.ErrorDetails{Type: "es_rejected_execution_exception"}}, ItemFailed, JOB2002},
+		{"unknown error", "index", &elastic.BulkResponseItem{Status: 500}, ItemFailed, JOB2002},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toBulkItemResult("id", tt.action, tt.item)
+			if got.ID != "id" || got.Result != tt.want {
+				t.Errorf("toBulkItemResult() = %v, want %v", got, tt.want)
+			}
+			if (got.Error == nil && tt.wantCode != "") || (got.Error != nil && got.Error.ErrCode != tt.wantCode) {
+				t.Errorf("toBulkItemResult() error = %v, want code %v", got.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_Get(t *testing.T) {
+	tests := []struct {
+		name     string
+		e        *ElasticSearch
+		want     json.RawMessage
+		wantCode string
+	}{
+		{"no client error", &ElasticSearch{}, nil, JOB2001},
+		{"not found error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs/_all/id1": {newResponse(404, `{"_index":"jobs","_type":"job","_id":"id1","found":false}`), nil}})}, nil, JOB1002},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs/_all/id1": {nil, errors.New("error on elastic")}})}, nil, JOB2002},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /jobs/_all/id1": {newResponse(200, `{"_index":"jobs","_type":"job","_id":"id1","found":true,"_source":`+string(successRawJSON())+`}`), nil}})}, successRawJSON(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.Get(context.TODO(), "jobs", "id1")
+			if (err == nil) != (tt.wantCode == "") {
+				t.Errorf("ElasticSearch.Get() error = %v, want code %v", err, tt.wantCode)
+				return
+			}
+			if err != nil && err.(*JobError).ErrCode != tt.wantCode {
+				t.Errorf("ElasticSearch.Get() error = %v, want code %v", err, tt.wantCode)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearch.Get() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_Suggest(t *testing.T) {
+	tests := []struct {
+		name     string
+		e        *ElasticSearch
+		prefix   string
+		want     []Suggestion
+		wantCode string
+	}{
+		{"empty prefix error", nil, "", nil, JOB1001},
+		{"no client error", &ElasticSearch{}, "anal", nil, JOB2001},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {nil, errors.New("error on elastic")}})}, "anal", nil, JOB2002},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {newResponse(200, `{"took":1,"timed_out":false,"hits":{"total":0,"max_score":0.0,"hits":[]},"suggest":{"suggestions":[{"text":"anal","offset":0,"length":4,"options":[{"text":"Analista de TI","_index":"jobs","_type":"job","_id":"id1","_score":1.0},{"text":"Analista Fiscal","_index":"jobs","_type":"job","_id":"id2","_score":1.0}]}]}}`), nil}})}, "anal", []Suggestion{{"Analista de TI", 1}, {"Analista Fiscal", 1}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.Suggest(context.TODO(), "jobs", "title.suggest", tt.prefix, 5)
+			if (err == nil) != (tt.wantCode == "") {
+				t.Errorf("ElasticSearch.Suggest() error = %v, want code %v", err, tt.wantCode)
+				return
+			}
+			if err != nil && err.(*JobError).ErrCode != tt.wantCode {
+				t.Errorf("ElasticSearch.Suggest() error = %v, want code %v", err, tt.wantCode)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearch.Suggest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_AddPercolator(t *testing.T) {
+	tests := []struct {
+		name     string
+		e        *ElasticSearch
+		wantCode string
+	}{
+		{"no client error", &ElasticSearch{}, JOB2001},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"PUT /alerts/percolator/id1": {nil, errors.New("error on elastic")}})}, JOB2002},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"PUT /alerts/percolator/id1": {newResponse(201, `{"_index":"alerts","_type":"percolator","_id":"id1","_version":1,"created":true}`), nil}})}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.e.AddPercolator(context.TODO(), "alerts", "id1", Query{Value: "java", Fields: []string{"title"}, Operator: "and"})
+			if (err == nil) != (tt.wantCode == "") {
+				t.Errorf("ElasticSearch.AddPercolator() error = %v, want code %v", err, tt.wantCode)
+				return
+			}
+			if err != nil && err.(*JobError).ErrCode != tt.wantCode {
+				t.Errorf("ElasticSearch.AddPercolator() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestElasticSearch_Percolate(t *testing.T) {
+	docs := []Indexable{Job{Title: "Desenvolvedor Java"}, Job{Title: "Analista de TI"}}
+	tests := []struct {
+		name     string
+		e        *ElasticSearch
+		docs     []Indexable
+		want     [][]string
+		wantCode string
+	}{
+		{"empty docs error", &ElasticSearch{}, nil, nil, JOB1001},
+		{"no client error", &ElasticSearch{}, docs, nil, JOB2001},
+		{"elastic search error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /_msearch": {nil, errors.New("error on elastic")}})}, docs, nil, JOB2002},
+		{"missing responses error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /_msearch": {newResponse(200, `{"responses":[{"hits":{"total":0,"hits":[]}}]}`), nil}})}, docs, nil, JOB2002},
+		{"response error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /_msearch": {newResponse(200, `{"responses":[{"hits":{"total":0,"hits":[]}},{"error":{"type":"query_shard_exception","reason":"no field mapping"}}]}`), nil}})}, docs, nil, JOB2002},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"GET /_msearch": {newResponse(200, `{"responses":[{"hits":{"total":2,"hits":[{"_index":"alerts","_type":"percolator","_id":"s1","_score":1.0},{"_index":"alerts","_type":"percolator","_id":"s2","_score":1.0}]}},{"hits":{"total":0,"hits":[]}}]}`), nil}})}, docs, [][]string{{"s1", "s2"}, nil}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.Percolate(context.TODO(), "alerts", tt.docs)
+			if (err == nil) != (tt.wantCode == "") {
+				t.Errorf("ElasticSearch.Percolate() error = %v, want code %v", err, tt.wantCode)
+				return
+			}
+			if err != nil && err.(*JobError).ErrCode != tt.wantCode {
+				t.Errorf("ElasticSearch.Percolate() error = %v, want code %v", err, tt.wantCode)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearch.Percolate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestElasticSearch_Search(t *testing.T) {
 	type args struct {
 		ctx     context.Context
 		index   string
-		sort    *Sort
+		sorts   []Sort
+		page    *Page
+		facets  []Facet
+		hl      *Highlight
 		queries []Query
 	}
 	tests := []struct {
 		name    string
 		e       *ElasticSearch
 		args    args
-		want    []json.RawMessage
+		want    *SearchResult
 		wantErr bool
 	}{
-		{"no query error", nil, args{context.TODO(), "jobs", nil, []Query{}}, nil, true},
-		{"no client error", &ElasticSearch{}, args{context.TODO(), "jobs", nil, []Query{Query{"something", []string{"field"}, "and"}}}, nil, true},
-		{"elastic connection error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", nil, []Query{Query{"something", []string{"field"}, "and"}}}, nil, true},
-		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {newResponse(200, successElasticResponseBody()), nil}})}, args{context.TODO(), "jobs", &Sort{"field1", false}, []Query{Query{"something", []string{"field"}, "and"}}}, []json.RawMessage{successRawJSON()}, false},
+		{"no query error", nil, args{context.TODO(), "jobs", nil, nil, nil, nil, []Query{}}, nil, true},
+		{"no client error", &ElasticSearch{}, args{context.TODO(), "jobs", nil, nil, nil, nil, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}}, nil, true},
+		{"elastic connection error", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {nil, errors.New("error on elastic")}})}, args{context.TODO(), "jobs", nil, nil, nil, nil, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}}, nil, true},
+		{"success", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {newResponse(200, successElasticResponseBody()), nil}})}, args{context.TODO(), "jobs", []Sort{{"field1", false}}, &Page{From: 0, Size: 10}, nil, nil, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}}, &SearchResult{Total: 1, Hits: []SearchHit{{ID: "5446c3eae70df005eb555870d7e7c7a9138b3d80", Score: floatPtr(6.9871044), Source: successRawJSON(), Highlight: map[string][]string{"title": {"<em>something</em>"}}}}, LastSort: []interface{}{1500.0, "job#5446c3eae70df005eb555870d7e7c7a9138b3d80"}}, false},
+		{"success with nested facet", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {newResponse(200, `{"took":2,"timed_out":false,"hits":{"total":3,"max_score":1.0,"hits":[]},"aggregations":{"state":{"doc_count":4,"state":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[{"key":"SC","doc_count":3,"hits":{"doc_count":2},"sum":{"value":5.0}},{"key":"RS","doc_count":1,"hits":{"doc_count":1},"sum":{"value":1.0}}]}}}}`), nil}})}, args{context.TODO(), "jobs", nil, nil, []Facet{{Name: "state", Field: "locations.state", Size: 30, Path: "locations", Sum: "locations.openings"}}, nil, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}}, &SearchResult{Total: 3, Facets: map[string][]FacetBucket{"state": {{Key: "SC", Count: 2, Sum: floatPtr(5)}, {Key: "RS", Count: 1, Sum: floatPtr(1)}}}}, false},
+		{"success with facets", &ElasticSearch{elasticClient: mockElasticClient(map[string]responseMock{"POST /jobs/_search": {newResponse(200, facetsElasticResponseBody()), nil}})}, args{context.TODO(), "jobs", nil, nil, []Facet{{Name: "city", Field: "cidade.keyword", Size: 10}, {Name: "salary", Field: "salario", Edges: []float64{1000, 2000}}}, nil, []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}}, &SearchResult{Total: 3, Facets: map[string][]FacetBucket{"city": {{Key: "Joinville", Count: 2}, {Key: "Canoas", Count: 1}}, "salary": {{Key: "*-1000.0", To: floatPtr(1000), Count: 0}, {Key: "1000.0-2000.0", From: floatPtr(1000), To: floatPtr(2000), Count: 1}, {Key: "2000.0-*", From: floatPtr(2000), Count: 2}}}}, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.e.Search(tt.args.ctx, tt.args.index, tt.args.sort, tt.args.queries...)
+			got, err := tt.e.Search(tt.args.ctx, tt.args.index, tt.args.sorts, tt.args.page, tt.args.facets, tt.args.hl, tt.args.queries...)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ElasticSearch.Search() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -100,14 +449,50 @@ func TestElasticSearch_Search(t *testing.T) {
 	}
 }
 
+func Test_createSearchBody(t *testing.T) {
+	query := Query{Value: "something", Fields: []string{"field"}, Operator: "and"}
+	tests := []struct {
+		name      string
+		sorts     []Sort
+		page      *Page
+		facets    []Facet
+		highlight *Highlight
+		want      string
+	}{
+		{"no sort and page", nil, nil, nil, nil, `{"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}}}`},
+		{"from and size", []Sort{{"salario", true}}, &Page{From: 20, Size: 10}, nil, nil, `{"from":20,"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}},"size":10,"sort":[{"salario":{"order":"asc"}},{"_uid":{"order":"asc"}}],"track_scores":true}`},
+		{"search after", []Sort{{"salario", false}}, &Page{From: 20, Size: 10, SearchAfter: []interface{}{1500.0, "job#id"}}, nil, nil, `{"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}},"search_after":[1500,"job#id"],"size":10,"sort":[{"salario":{"order":"desc"}},{"_uid":{"order":"asc"}}],"track_scores":true}`},
+		{"multiple sorts", []Sort{{ScoreField, false}, {"salario", false}}, &Page{From: 0, Size: 10}, nil, nil, `{"from":0,"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}},"size":10,"sort":[{"_score":{"order":"desc"}},{"salario":{"order":"desc"}},{"_uid":{"order":"asc"}}],"track_scores":true}`},
+		{"highlight", nil, nil, nil, &Highlight{Fields: []string{"title", "description"}, PreTag: "[[", PostTag: "]]", FragmentSize: 100, Fragments: 2}, `{"highlight":{"fields":{"description":{},"title":{}},"fragment_size":100,"number_of_fragments":2,"post_tags":["]]"],"pre_tags":["[["]},"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}}}`},
+		{"nested facet", nil, nil, []Facet{{Name: "state", Field: "locations.state", Size: 30, Path: "locations", Sum: "locations.openings"}}, nil, `{"aggregations":{"state":{"aggregations":{"state":{"aggregations":{"hits":{"reverse_nested":{}},"sum":{"sum":{"field":"locations.openings"}}},"terms":{"field":"locations.state","size":30}}},"nested":{"path":"locations"}}},"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}}}`},
+		{"facets", nil, nil, []Facet{{Name: "city", Field: "cidade.keyword", Size: 5}, {Name: "salary", Field: "salario", Edges: []float64{1000, 2000}}}, nil, `{"aggregations":{"city":{"terms":{"field":"cidade.keyword","size":5}},"salary":{"range":{"field":"salario","ranges":[{"to":1000},{"from":1000,"to":2000},{"from":2000}]}}},"query":{"simple_query_string":{"analyze_wildcard":true,"default_operator":"and","fields":["field"],"query":"something"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := createSearchBody(tt.sorts, tt.page, tt.facets, tt.highlight, query)
+			if err != nil {
+				t.Fatalf("createSearchBody() error = %v", err)
+			}
+			got, _ := json.Marshal(body)
+			if string(got) != tt.want {
+				t.Errorf("createSearchBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_createElasticCompoundQuery(t *testing.T) {
 	tests := []struct {
 		name string
 		args []Query
 		want elastic.Query
 	}{
-		{"single query", []Query{Query{"something", []string{"field"}, "and"}}, elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)},
-		{"multiple query", []Query{Query{"something", []string{"field"}, "and"}, Query{"another thing", []string{"field2"}, "and"}}, elastic.NewBoolQuery().Must(elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)).Must(elastic.NewSimpleQueryStringQuery("another thing").DefaultOperator("and").Field("field2").AnalyzeWildcard(true))},
+		{"single query", []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}}, elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)},
+		{"multiple query", []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}, Query{Value: "another thing", Fields: []string{"field2"}, Operator: "and"}}, elastic.NewBoolQuery().Must(elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)).Must(elastic.NewSimpleQueryStringQuery("another thing").DefaultOperator("and").Field("field2").AnalyzeWildcard(true))},
+		{"query and range", []Query{Query{Value: "something", Fields: []string{"field"}, Operator: "and"}, Query{Range: &Range{Field: "salario", Min: floatPtr(1000)}}}, elastic.NewBoolQuery().Must(elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)).Filter(elastic.NewRangeQuery("salario").Gte(1000.0))},
+		{"nested term", []Query{Query{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}}}, elastic.NewBoolQuery().Filter(elastic.NewNestedQuery("locations", elastic.NewTermQuery("locations.state", "SC")))},
+		{"single range", []Query{Query{Range: &Range{Field: "salario", Min: floatPtr(1000), Max: floatPtr(2000)}}}, elastic.NewBoolQuery().Filter(elastic.NewRangeQuery("salario").Gte(1000.0).Lte(2000.0))},
+		{"exclusive range", []Query{Query{Range: &Range{Field: "salario", Min: floatPtr(1000), Max: floatPtr(2000), ExcludeMin: true, ExcludeMax: true}}}, elastic.NewBoolQuery().Filter(elastic.NewRangeQuery("salario").Gt(1000.0).Lt(2000.0))},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -124,7 +509,19 @@ func Test_createElasticQuery(t *testing.T) {
 		arg  Query
 		want elastic.Query
 	}{
-		{"simple query string", Query{"something", []string{"field"}, "and"}, elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)},
+		{"simple query string", Query{Value: "something", Fields: []string{"field"}, Operator: "and"}, elastic.NewSimpleQueryStringQuery("something").DefaultOperator("and").Field("field").AnalyzeWildcard(true)},
+		{"plain words", Query{Value: "c++", Fields: []string{"title^3", "description"}, Operator: "and", Match: MatchWords}, elastic.NewMultiMatchQuery("c++", "title^3", "description").Operator("and")},
+		{"plain fuzzy words", Query{Value: "java*", Fields: []string{"title"}, Operator: "and", Fuzziness: "AUTO", Match: MatchWords}, elastic.NewMultiMatchQuery("java*", "title").Operator("and").Fuzziness("AUTO")},
+		{"plain phrase", Query{Value: "foo|bar baz", Fields: []string{"title"}, Operator: "and", Match: MatchPhrase}, elastic.NewMultiMatchQuery("foo|bar baz", "title").Type("phrase")},
+		{"more like this", Query{Fields: []string{"title", "description"}, Like: &Like{Type: "job", IDs: []string{"id1"}}}, elastic.NewMoreLikeThisQuery().Field("title", "description").MinTermFreq(1).MinDocFreq(1).Include(false).LikeItems(elastic.NewMoreLikeThisQueryItem().Type("job").Id("id1"))},
+		{"fuzzy word", Query{Value: "analsita", Fields: []string{"title^3", "description"}, Operator: "and", Fuzziness: "AUTO"}, elastic.NewMultiMatchQuery("analsita", "title^3", "description").Fuzziness("AUTO")},
+		{"bool", Query{Bool: &Bool{Must: []Query{{Value: "java", Fields: []string{"title"}, Operator: "and"}, {Range: &Range{Field: "salario", Min: floatPtr(3000)}}}, MustNot: []Query{{Term: &Term{Field: "cidadeFormated", Value: "Joinville - SC"}}}}}, elastic.NewBoolQuery().Must(elastic.NewSimpleQueryStringQuery("java").DefaultOperator("and").Field("title").AnalyzeWildcard(true)).Filter(elastic.NewRangeQuery("salario").Gte(3000.0)).MustNot(elastic.NewTermQuery("cidadeFormated", "Joinville - SC"))},
+		{"bool should", Query{Bool: &Bool{Should: []Query{{Value: "java", Fields: []string{"title"}, Operator: "and"}, {Value: "golang", Fields: []string{"title"}, Operator: "and"}}}}, elastic.NewBoolQuery().Should(elastic.NewSimpleQueryStringQuery("java").DefaultOperator("and").Field("title").AnalyzeWildcard(true)).Should(elastic.NewSimpleQueryStringQuery("golang").DefaultOperator("and").Field("title").AnalyzeWildcard(true)).MinimumNumberShouldMatch(1)},
+		{"fuzzy words, phrase and prefix", Query{Value: `analsita "de sistemas" jav*`, Fields: []string{"title^3"}, Operator: "and", Fuzziness: "1"}, elastic.NewBoolQuery().Must(elastic.NewMultiMatchQuery("analsita", "title^3").Fuzziness("1")).Must(elastic.NewMultiMatchQuery("de sistemas", "title^3").Type("phrase")).Must(elastic.NewSimpleQueryStringQuery("jav*").DefaultOperator("and").Field("title^3").AnalyzeWildcard(true))},
+		{"fuzzy excluded words", Query{Value: `analsita -junoir -"de sistemas"`, Fields: []string{"title"}, Operator: "and", Fuzziness: "1"}, elastic.NewBoolQuery().Must(elastic.NewMultiMatchQuery("analsita", "title").Fuzziness("1")).MustNot(elastic.NewMultiMatchQuery("junoir", "title").Fuzziness("1")).MustNot(elastic.NewMultiMatchQuery("de sistemas", "title").Type("phrase"))},
+		{"fuzzy or words", Query{Value: "analsita|desenvolvedro", Fields: []string{"title"}, Operator: "and", Fuzziness: "1"}, elastic.NewBoolQuery().Should(elastic.NewMultiMatchQuery("analsita", "title").Fuzziness("1")).Should(elastic.NewMultiMatchQuery("desenvolvedro", "title").Fuzziness("1")).MinimumNumberShouldMatch(1)},
+		{"fuzzy or groups", Query{Value: "analsita +jaav | golnag", Fields: []string{"title"}, Operator: "or", Fuzziness: "1"}, elastic.NewBoolQuery().Should(elastic.NewBoolQuery().Must(elastic.NewMultiMatchQuery("analsita", "title").Fuzziness("1")).Must(elastic.NewMultiMatchQuery("jaav", "title").Fuzziness("1"))).Should(elastic.NewMultiMatchQuery("golnag", "title").Fuzziness("1")).MinimumNumberShouldMatch(1)},
+		{"fuzzy words with or operator", Query{Value: "analsita jaav -junoir", Fields: []string{"title"}, Operator: "or", Fuzziness: "1"}, elastic.NewBoolQuery().Should(elastic.NewMultiMatchQuery("analsita", "title").Fuzziness("1")).Should(elastic.NewMultiMatchQuery("jaav", "title").Fuzziness("1")).MinimumNumberShouldMatch(1).MustNot(elastic.NewMultiMatchQuery("junoir", "title").Fuzziness("1"))},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -156,12 +553,16 @@ func mockElasticClient(resp map[string]responseMock) *elastic.Client {
 func mockHTTPClient(resp map[string]responseMock) *http.Client {
 	fn := func(r *http.Request) (*http.Response, error) {
 		path := r.Method + " " + r.URL.Path
-		for k, v := range resp {
-			if strings.HasPrefix(path, k) {
-				return v.resp, v.err
+		match := ""
+		for k := range resp {
+			if strings.HasPrefix(path, k) && len(k) > len(match) {
+				match = k
 			}
 		}
-		return nil, fmt.Errorf("unmapped request for uri: %s", path)
+		if match == "" {
+			return nil, fmt.Errorf("unmapped request for uri: %s", path)
+		}
+		return resp[match].resp, resp[match].err
 	}
 	return &http.Client{Transport: &mockRoundTripper{fn: fn}, CheckRedirect: nil, Jar: nil, Timeout: 0}
 }
@@ -188,10 +589,26 @@ func (d *dummyReadCloser) Close() error {
 }
 
 func successElasticResponseBody() string {
-	return `{"took":199,"timed_out":false,"_shards":{"total":5,"successful":5,"failed":0},"hits":{"total":1,"max_score":6.9871044,"hits":[{"_index":"jobs","_type":"job","_id":"5446c3eae70df005eb555870d7e7c7a9138b3d80","_score":6.9871044,"_source":{"title":"Assistente de Contabilidade","description":"<li> Realizar classificação, conciliação e lançamento contábil e participar na apuração de impostos e preenchimento de guias de recolhimento junto aos órgãos do governo. Controlar escrituração de livros fiscais e auxiliar na elaboração de balancetes e demonstrativos de contabilidade.</li>","salario":1500,"cidade":["Canoas"],"cidadeFormated":["Canoas - RS (1)"]}}]}}`
+	return `{"took":199,"timed_out":false,"_shards":{"total":5,"successful":5,"failed":0},"hits":{"total":1,"max_score":6.9871044,"hits":[{"_index":"jobs","_type":"job","_id":"5446c3eae70df005eb555870d7e7c7a9138b3d80","_score":6.9871044,"_source":{"title":"Assistente de Contabilidade","description":"<li> Realizar classificação, conciliação e lançamento contábil e participar na apuração de impostos e preenchimento de guias de recolhimento junto aos órgãos do governo. Controlar escrituração de livros fiscais e auxiliar na elaboração de balancetes e demonstrativos de contabilidade.</li>","salario":1500,"cidade":["Canoas"],"cidadeFormated":["Canoas - RS (1)"]},"sort":[1500.0,"job#5446c3eae70df005eb555870d7e7c7a9138b3d80"],"highlight":{"title":["<em>something</em>"]}}]}}`
 }
 
 func successRawJSON() json.RawMessage {
 	body := `{"title":"Assistente de Contabilidade","description":"<li> Realizar classificação, conciliação e lançamento contábil e participar na apuração de impostos e preenchimento de guias de recolhimento junto aos órgãos do governo. Controlar escrituração de livros fiscais e auxiliar na elaboração de balancetes e demonstrativos de contabilidade.</li>","salario":1500,"cidade":["Canoas"],"cidadeFormated":["Canoas - RS (1)"]}`
 	return json.RawMessage([]byte(body))
 }
+
+func successBulkResponseBody() string {
+	return `{"took":3,"errors":false,"items":[{"index":{"_index":"jobs","_type":"job","_id":"e2e6b9ef4d0c4b2b1f3fb7e0e8e4d7b1f55d3e2a","_version":1,"status":201}}]}`
+}
+
+func failedBulkResponseBody() string {
+	return `{"took":3,"errors":true,"items":[{"index":{"_index":"jobs","_type":"job","_id":"e2e6b9ef4d0c4b2b1f3fb7e0e8e4d7b1f55d3e2a","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse [salario]"}}}]}`
+}
+
+func bulkDeleteResponseBody() string {
+	return `{"took":3,"errors":false,"items":[{"delete":{"_index":"jobs","_type":"job","_id":"id1","_version":2,"status":200,"found":true}},{"delete":{"_index":"jobs","_type":"job","_id":"id2","_version":1,"status":404,"found":false}}]}`
+}
+
+func facetsElasticResponseBody() string {
+	return `{"took":2,"timed_out":false,"hits":{"total":3,"max_score":1.0,"hits":[]},"aggregations":{"city":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[{"key":"Joinville","doc_count":2},{"key":"Canoas","doc_count":1}]},"salary":{"buckets":[{"key":"*-1000.0","to":1000.0,"doc_count":0},{"key":"1000.0-2000.0","from":1000.0,"to":2000.0,"doc_count":1},{"key":"2000.0-*","from":2000.0,"doc_count":2}]}}}`
+}
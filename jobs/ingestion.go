@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// ingestionItem job waiting on queue to be indexed
+type ingestionItem struct {
+	ingestionID string
+	tenant      string
+	position    int
+	job         Job
+}
+
+// bulkIngester queue of jobs drained by a pool of workers sending bulk requests to repository, created jobs are sent to alerter when set
+type bulkIngester struct {
+	repository    JobRepository
+	store         IngestionStore
+	alerter       *jobAlerter
+	queue         chan ingestionItem
+	batchSize     int
+	flushInterval time.Duration
+	flushTimeout  time.Duration
+	wg            sync.WaitGroup
+}
+
+// newBulkIngester bulkIngester constructor, starts workers. each bulk request of a tenant is canceled after flushTimeout
+func newBulkIngester(repository JobRepository, store IngestionStore, alerter *jobAlerter, workers, batchSize, queueSize int, flushInterval, flushTimeout time.Duration) *bulkIngester {
+	if workers < 1 {
+		workers = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	if flushTimeout <= 0 {
+		flushTimeout = time.Minute
+	}
+	i := &bulkIngester{repository: repository, store: store, alerter: alerter, queue: make(chan ingestionItem, queueSize), batchSize: batchSize, flushInterval: flushInterval, flushTimeout: flushTimeout}
+	i.wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go i.worker()
+	}
+	return i
+}
+
+// enqueue add jobs on queue and returns the ingestion id. when the request is canceled after some jobs were queued,
+// the other jobs are reported as failed on the ingestion and the id is returned without error, as the queued ones are indexed
+func (i *bulkIngester) enqueue(ctx context.Context, jobs []Job) (string, error) {
+	id := newRandomID()
+	documentIDs := make([]string, len(jobs))
+	for n, job := range jobs {
+		documentIDs[n] = job.ID()
+	}
+	if err := i.store.Create(ctx, id, documentIDs); err != nil {
+		return "", err
+	}
+
+	for n, job := range jobs {
+		select {
+		case i.queue <- ingestionItem{ingestionID: id, tenant: TenantFromContext(ctx), position: n, job: job}:
+		case <-ctx.Done():
+			err := NewUnknownError("request canceled while enqueuing jobs")
+			queued := n
+			for ; n < len(jobs); n++ {
+				i.report(context.Background(), id, n, BulkItemResult{ID: documentIDs[n], Result: ItemFailed, Error: err})
+			}
+			if queued == 0 {
+				return id, err
+			}
+			log.Printf("message=\"request canceled while enqueuing jobs\" kind=ingestion ingestion=%s tenant=%s size=%d queued=%d", id, TenantFromContext(ctx), len(jobs), queued)
+			return id, nil
+		}
+	}
+	log.Printf("message=\"jobs enqueued\" kind=ingestion ingestion=%s tenant=%s size=%d", id, TenantFromContext(ctx), len(jobs))
+	return id, nil
+}
+
+// close stops receiving jobs and waits for workers to flush the queue and for alerter to send the alerts of flushed jobs
+func (i *bulkIngester) close() {
+	close(i.queue)
+	i.wg.Wait()
+	if i.alerter != nil {
+		i.alerter.close()
+	}
+}
+
+func (i *bulkIngester) worker() {
+	defer i.wg.Done()
+	ticker := time.NewTicker(i.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ingestionItem, 0, i.batchSize)
+	for {
+		select {
+		case item, ok := <-i.queue:
+			if !ok {
+				i.flush(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= i.batchSize {
+				i.flush(batch)
+				batch = make([]ingestionItem, 0, i.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				i.flush(batch)
+				batch = make([]ingestionItem, 0, i.batchSize)
+			}
+		}
+	}
+}
+
+// flush indexes batch with a bulk request for each tenant, a stuck request fails its jobs after flushTimeout and does not hold the worker
+func (i *bulkIngester) flush(batch []ingestionItem) {
+	var tenants []string
+	byTenant := make(map[string][]ingestionItem)
+	for _, item := range batch {
+		if _, ok := byTenant[item.tenant]; !ok {
+			tenants = append(tenants, item.tenant)
+		}
+		byTenant[item.tenant] = append(byTenant[item.tenant], item)
+	}
+	for _, tenant := range tenants {
+		ctx, cancel := context.WithTimeout(ContextWithTenant(context.Background(), tenant), i.flushTimeout)
+		i.flushTenant(ctx, byTenant[tenant])
+		cancel()
+	}
+}
+
+func (i *bulkIngester) flushTenant(ctx context.Context, batch []ingestionItem) {
+	if len(batch) == 0 {
+		return
+	}
+	jobs := make([]Job, len(batch))
+	started := make(map[string]bool)
+	for n, item := range batch {
+		jobs[n] = item.job
+		if !started[item.ingestionID] {
+			started[item.ingestionID] = true
+			if err := i.store.Start(ctx, item.ingestionID); err != nil {
+				log.Printf("message=\"error updating ingestion status\" kind=ingestion ingestion=%s error=\"%s\"", item.ingestionID, err.Error())
+			}
+		}
+	}
+
+	results, err := i.repository.AddAll(ctx, jobs)
+	if err != nil {
+		log.Printf("message=\"error indexing jobs\" kind=ingestion tenant=%s size=%d error=\"%s\"", TenantFromContext(ctx), len(batch), err.Error())
+		jobErr, ok := err.(*JobError)
+		if !ok {
+			jobErr = NewUnknownError(err.Error())
+		}
+		for _, item := range batch {
+			i.report(ctx, item.ingestionID, item.position, BulkItemResult{ID: item.job.ID(), Result: ItemFailed, Error: jobErr})
+		}
+		return
+	}
+	for n, item := range batch {
+		i.report(ctx, item.ingestionID, item.position, results[n])
+	}
+	log.Printf("message=\"jobs indexed\" kind=ingestion tenant=%s size=%d", TenantFromContext(ctx), len(batch))
+	i.alert(ctx, batch, results)
+}
+
+// alert enqueues created jobs on alerter, updated jobs were already alerted when created
+func (i *bulkIngester) alert(ctx context.Context, batch []ingestionItem, results []BulkItemResult) {
+	if i.alerter == nil {
+		return
+	}
+	var created []Job
+	for n, item := range batch {
+		if results[n].Result == ItemCreated {
+			created = append(created, item.job)
+		}
+	}
+	i.alerter.enqueue(ctx, created)
+}
+
+func (i *bulkIngester) report(ctx context.Context, ingestionID string, position int, result BulkItemResult) {
+	if err := i.store.Report(ctx, ingestionID, position, result); err != nil {
+		log.Printf("message=\"error updating ingestion status\" kind=ingestion ingestion=%s error=\"%s\"", ingestionID, err.Error())
+	}
+}
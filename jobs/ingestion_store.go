@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IngestionStore tracks the status of ingestions and the result of each document
+type IngestionStore interface {
+	Create(ctx context.Context, id string, documentIDs []string) error
+	Start(ctx context.Context, id string) error
+	Report(ctx context.Context, id string, position int, result BulkItemResult) error
+	Get(ctx context.Context, id string) (*Ingestion, error)
+}
+
+// MemoryIngestionStore IngestionStore impl in memory, keeps ingestions during a retention window
+type MemoryIngestionStore struct {
+	ingestions map[string]*Ingestion
+	order      []string
+	retention  time.Duration
+	maxEntries int
+	now        func() time.Time
+	rmutex     sync.RWMutex
+}
+
+// newMemoryIngestionStore MemoryIngestionStore constructor
+func newMemoryIngestionStore(retention time.Duration, maxEntries int) *MemoryIngestionStore {
+	return &MemoryIngestionStore{ingestions: make(map[string]*Ingestion), retention: retention, maxEntries: maxEntries, now: time.Now}
+}
+
+// Create starts tracking an ingestion as queued
+func (s *MemoryIngestionStore) Create(ctx context.Context, id string, documentIDs []string) error {
+	s.rmutex.Lock()
+	defer s.rmutex.Unlock()
+	if _, exists := s.ingestions[id]; exists {
+		return NewInvalidRequestError(fmt.Sprintf("ingestion %s already exists", id))
+	}
+	if !s.expire() {
+		return NewUnavailableError(fmt.Sprintf("ingestion store is full with %d unfinished ingestions, try again later", len(s.order)))
+	}
+
+	now := s.now()
+	docs := make([]DocumentResult, len(documentIDs))
+	for i, docID := range documentIDs {
+		docs[i] = DocumentResult{ID: docID, Result: ItemPending}
+	}
+	s.ingestions[id] = &Ingestion{ID: id, Tenant: TenantFromContext(ctx), Status: IngestionQueued, Total: len(docs), Pending: len(docs), Documents: docs, CreatedAt: now, UpdatedAt: now}
+	s.order = append(s.order, id)
+	return nil
+}
+
+// Start marks ingestion as in progress
+func (s *MemoryIngestionStore) Start(ctx context.Context, id string) error {
+	s.rmutex.Lock()
+	defer s.rmutex.Unlock()
+	ingestion, ok := s.ingestions[id]
+	if !ok {
+		return NewNotFoundError(fmt.Sprintf("ingestion %s not found", id))
+	}
+	if ingestion.Status == IngestionQueued {
+		ingestion.Status = IngestionInProgress
+		ingestion.UpdatedAt = s.now()
+	}
+	return nil
+}
+
+// Report records the result of the document on position, finishing the ingestion when no document is pending
+func (s *MemoryIngestionStore) Report(ctx context.Context, id string, position int, result BulkItemResult) error {
+	s.rmutex.Lock()
+	defer s.rmutex.Unlock()
+	ingestion, ok := s.ingestions[id]
+	if !ok {
+		return NewNotFoundError(fmt.Sprintf("ingestion %s not found", id))
+	}
+	if position < 0 || position >= len(ingestion.Documents) {
+		return NewInvalidRequestError(fmt.Sprintf("invalid document position %d for ingestion %s", position, id))
+	}
+	doc := &ingestion.Documents[position]
+	if doc.Result != ItemPending {
+		return nil
+	}
+
+	doc.Result = result.Result
+	switch result.Result {
+	case ItemCreated:
+		ingestion.Created++
+	case ItemUpdated:
+		ingestion.Updated++
+	default:
+		doc.Result = ItemFailed
+		if result.Error != nil {
+			doc.Error = result.Error.ErrCode
+		}
+		ingestion.Failed++
+	}
+	ingestion.Pending--
+
+	switch {
+	case ingestion.Pending > 0:
+		ingestion.Status = IngestionInProgress
+	case ingestion.Failed == ingestion.Total:
+		ingestion.Status = IngestionFailed
+	default:
+		ingestion.Status = IngestionDone
+	}
+	ingestion.UpdatedAt = s.now()
+	return nil
+}
+
+// Get returns a copy of the ingestion, ingestions of other tenants are not found
+func (s *MemoryIngestionStore) Get(ctx context.Context, id string) (*Ingestion, error) {
+	s.rmutex.RLock()
+	defer s.rmutex.RUnlock()
+	ingestion, ok := s.ingestions[id]
+	if !ok || s.expired(ingestion) || ingestion.Tenant != TenantFromContext(ctx) {
+		return nil, NewNotFoundError(fmt.Sprintf("ingestion %s not found", id))
+	}
+	result := *ingestion
+	result.Documents = append([]DocumentResult(nil), ingestion.Documents...)
+	return &result, nil
+}
+
+func (s *MemoryIngestionStore) expired(ingestion *Ingestion) bool {
+	return ingestionFinished(ingestion) && s.retention > 0 && s.now().Sub(ingestion.UpdatedAt) > s.retention
+}
+
+func ingestionFinished(ingestion *Ingestion) bool {
+	return ingestion.Status == IngestionDone || ingestion.Status == IngestionFailed
+}
+
+// expire removes finished ingestions older than retention and the oldest finished ones when over max entries,
+// returns false when there is no room for a new ingestion because every kept ingestion is unfinished
+func (s *MemoryIngestionStore) expire() bool {
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if s.expired(s.ingestions[id]) {
+			delete(s.ingestions, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+
+	if s.maxEntries <= 0 || len(s.order) < s.maxEntries {
+		return true
+	}
+	evict := len(s.order) - s.maxEntries + 1
+	kept = s.order[:0]
+	for _, id := range s.order {
+		if evict > 0 && ingestionFinished(s.ingestions[id]) {
+			delete(s.ingestions, id)
+			evict--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+	return evict == 0
+}
@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryIngestionStore_Report(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     map[int]BulkItemResult
+		wantStatus  IngestionStatus
+		wantPending int
+		wantFailed  int
+	}{
+		{"queued", map[int]BulkItemResult{}, IngestionQueued, 2, 0},
+		{"in progress", map[int]BulkItemResult{0: {Result: ItemCreated}}, IngestionInProgress, 1, 0},
+		{"done", map[int]BulkItemResult{0: {Result: ItemCreated}, 1: {Result: ItemFailed, Error: NewParserError("error")}}, IngestionDone, 0, 1},
+		{"failed", map[int]BulkItemResult{0: {Result: ItemFailed}, 1: {Result: ItemFailed}}, IngestionFailed, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMemoryIngestionStore(time.Hour, 10)
+			s.Create(context.TODO(), "id", []string{"doc1", "doc2"})
+			for position, result := range tt.results {
+				if err := s.Report(context.TODO(), "id", position, result); err != nil {
+					t.Fatalf("MemoryIngestionStore.Report() error = %v", err)
+				}
+			}
+			got, err := s.Get(context.TODO(), "id")
+			if err != nil {
+				t.Fatalf("MemoryIngestionStore.Get() error = %v", err)
+			}
+			if got.Status != tt.wantStatus || got.Pending != tt.wantPending || got.Failed != tt.wantFailed {
+				t.Errorf("MemoryIngestionStore.Get() = %+v, want status %v, pending %d, failed %d", got, tt.wantStatus, tt.wantPending, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestMemoryIngestionStore_errors(t *testing.T) {
+	s := newMemoryIngestionStore(time.Hour, 10)
+	s.Create(context.TODO(), "id", []string{"doc1"})
+	if err := s.Create(context.TODO(), "id", []string{"doc1"}); err == nil {
+		t.Errorf("MemoryIngestionStore.Create() duplicated id, want error")
+	}
+	if err := s.Start(context.TODO(), "unknown"); err == nil {
+		t.Errorf("MemoryIngestionStore.Start() unknown id, want error")
+	}
+	if err := s.Report(context.TODO(), "id", 1, BulkItemResult{Result: ItemCreated}); err == nil {
+		t.Errorf("MemoryIngestionStore.Report() invalid position, want error")
+	}
+	if _, err := s.Get(context.TODO(), "unknown"); err == nil {
+		t.Errorf("MemoryIngestionStore.Get() unknown id, want error")
+	}
+	if _, err := s.Get(ContextWithTenant(context.TODO(), "acme"), "id"); err == nil {
+		t.Errorf("MemoryIngestionStore.Get() ingestion of other tenant, want error")
+	}
+}
+
+func TestMemoryIngestionStore_retention(t *testing.T) {
+	now := time.Now()
+	s := newMemoryIngestionStore(time.Minute, 2)
+	s.now = func() time.Time { return now }
+
+	s.Create(context.TODO(), "finished", []string{"doc1"})
+	s.Report(context.TODO(), "finished", 0, BulkItemResult{Result: ItemCreated})
+	s.Create(context.TODO(), "running", []string{"doc1"})
+
+	now = now.Add(2 * time.Minute)
+	if _, err := s.Get(context.TODO(), "finished"); err == nil {
+		t.Errorf("MemoryIngestionStore.Get() finished ingestion after retention, want error")
+	}
+	if _, err := s.Get(context.TODO(), "running"); err != nil {
+		t.Errorf("MemoryIngestionStore.Get() running ingestion after retention, error = %v", err)
+	}
+
+	s.Create(context.TODO(), "second", []string{"doc1"})
+	s.Report(context.TODO(), "second", 0, BulkItemResult{Result: ItemCreated})
+	if err := s.Create(context.TODO(), "third", []string{"doc1"}); err != nil {
+		t.Fatalf("MemoryIngestionStore.Create() over max entries with finished ingestion, error = %v", err)
+	}
+	if _, err := s.Get(context.TODO(), "second"); err == nil {
+		t.Errorf("MemoryIngestionStore.Get() oldest finished ingestion over max entries, want error")
+	}
+	if _, err := s.Get(context.TODO(), "running"); err != nil {
+		t.Errorf("MemoryIngestionStore.Get() unfinished ingestion over max entries, error = %v", err)
+	}
+	if len(s.ingestions) != 2 {
+		t.Errorf("MemoryIngestionStore kept %d ingestions, want 2", len(s.ingestions))
+	}
+
+	if err := s.Create(context.TODO(), "fourth", []string{"doc1"}); err == nil || err.(*JobError).ErrCode != JOB1005 {
+		t.Errorf("MemoryIngestionStore.Create() full of unfinished ingestions, error = %v, want %s", err, JOB1005)
+	}
+	if _, err := s.Get(context.TODO(), "third"); err != nil {
+		t.Errorf("MemoryIngestionStore.Get() unfinished ingestion when full, error = %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBulkIngester_batches(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchSize int
+		jobs      int
+		addErr    error
+		wantSizes []int
+	}{
+		{"single batch", 10, 3, nil, []int{3}},
+		{"split on batch size", 2, 5, nil, []int{2, 2, 1}},
+		{"repository error keeps draining", 2, 3, errors.New("error on bulk add"), []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var sizes []int
+			repository := &mockJobRepository{addAllFn: func(jobs []Job) ([]BulkItemResult, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				sizes = append(sizes, len(jobs))
+				return createdResults(jobs), tt.addErr
+			}}
+			i := newBulkIngester(repository, newMemoryIngestionStore(0, 0), nil, 1, tt.batchSize, tt.jobs, time.Hour, time.Minute)
+			if _, err := i.enqueue(context.TODO(), make([]Job, tt.jobs)); err != nil {
+				t.Fatalf("bulkIngester.enqueue() error = %v", err)
+			}
+			i.close()
+
+			if len(sizes) != len(tt.wantSizes) {
+				t.Fatalf("bulkIngester batches = %v, want %v", sizes, tt.wantSizes)
+			}
+			for n := range sizes {
+				if sizes[n] != tt.wantSizes[n] {
+					t.Errorf("bulkIngester batches = %v, want %v", sizes, tt.wantSizes)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkIngester_flushInterval(t *testing.T) {
+	flushed := make(chan int, 1)
+	repository := &mockJobRepository{addAllFn: func(jobs []Job) ([]BulkItemResult, error) {
+		flushed <- len(jobs)
+		return createdResults(jobs), nil
+	}}
+	i := newBulkIngester(repository, newMemoryIngestionStore(0, 0), nil, 1, 100, 10, 10*time.Millisecond, time.Minute)
+	defer i.close()
+	if _, err := i.enqueue(context.TODO(), []Job{Job{}}); err != nil {
+		t.Fatalf("bulkIngester.enqueue() error = %v", err)
+	}
+
+	select {
+	case size := <-flushed:
+		if size != 1 {
+			t.Errorf("bulkIngester flushed %d jobs, want 1", size)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("bulkIngester did not flush after interval")
+	}
+}
+
+func TestBulkIngester_tracking(t *testing.T) {
+	jobs := []Job{Job{Title: "a"}, Job{Title: "b"}, Job{Title: "c"}}
+	tests := []struct {
+		name       string
+		addAllFn   func(jobs []Job) ([]BulkItemResult, error)
+		wantStatus IngestionStatus
+		wantDocs   []DocumentResult
+	}{
+		{"done with partial failure", func(jobs []Job) ([]BulkItemResult, error) {
+			return []BulkItemResult{{ID: jobs[0].ID(), Result: ItemCreated}, {ID: jobs[1].ID(), Result: ItemUpdated}, {ID: jobs[2].ID(), Result: ItemFailed, Error: NewElasticsearchAccessError("error")}}, nil
+		}, IngestionDone, []DocumentResult{{jobs[0].ID(), ItemCreated, ""}, {jobs[1].ID(), ItemUpdated, ""}, {jobs[2].ID(), ItemFailed, JOB2002}}},
+		{"failed on repository error", func(jobs []Job) ([]BulkItemResult, error) {
+			return nil, NewElasticsearchConnectError("error")
+		}, IngestionFailed, []DocumentResult{{jobs[0].ID(), ItemFailed, JOB2001}, {jobs[1].ID(), ItemFailed, JOB2001}, {jobs[2].ID(), ItemFailed, JOB2001}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIngestionStore(0, 0)
+			i := newBulkIngester(&mockJobRepository{addAllFn: tt.addAllFn}, store, nil, 1, 10, 10, time.Hour, time.Minute)
+			id, err := i.enqueue(context.TODO(), jobs)
+			if err != nil {
+				t.Fatalf("bulkIngester.enqueue() error = %v", err)
+			}
+			i.close()
+
+			got, err := store.Get(context.TODO(), id)
+			if err != nil {
+				t.Fatalf("MemoryIngestionStore.Get() error = %v", err)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("ingestion status = %v, want %v", got.Status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(got.Documents, tt.wantDocs) {
+				t.Errorf("ingestion docs = %v, want %v", got.Documents, tt.wantDocs)
+			}
+		})
+	}
+}
+
+func TestBulkIngester_flushTimeout(t *testing.T) {
+	store := newMemoryIngestionStore(0, 0)
+	i := newBulkIngester(blockingJobRepository{}, store, nil, 1, 10, 10, time.Hour, 10*time.Millisecond)
+	id, err := i.enqueue(context.TODO(), []Job{Job{Title: "a"}})
+	if err != nil {
+		t.Fatalf("bulkIngester.enqueue() error = %v", err)
+	}
+	i.close()
+
+	got, err := store.Get(context.TODO(), id)
+	if err != nil {
+		t.Fatalf("MemoryIngestionStore.Get() error = %v", err)
+	}
+	if got.Status != IngestionFailed || got.Documents[0].Error != JOB2002 {
+		t.Errorf("ingestion = %+v, want failed with %s", got, JOB2002)
+	}
+}
+
+// blockingJobRepository bulk requests wait until canceled, like a stuck elasticsearch
+type blockingJobRepository struct {
+	mockJobRepository
+}
+
+func (r blockingJobRepository) AddAll(ctx context.Context, jobs []Job) ([]BulkItemResult, error) {
+	<-ctx.Done()
+	return nil, NewElasticsearchAccessError(ctx.Err().Error())
+}
+
+func TestBulkIngester_alert(t *testing.T) {
+	jobs := []Job{Job{Title: "a"}, Job{Title: "b"}, Job{Title: "c"}}
+	var matched []Job
+	alerter := newJobAlerter(&mockAlertRepository{matchFn: func(jobs []Job) ([][]string, error) {
+		matched = jobs
+		return make([][]string, len(jobs)), nil
+	}}, mockAlertSink(func([]Alert) error { return nil }), 10, 0, 0)
+	repository := &mockJobRepository{addAllFn: func(jobs []Job) ([]BulkItemResult, error) {
+		return []BulkItemResult{{ID: jobs[0].ID(), Result: ItemCreated}, {ID: jobs[1].ID(), Result: ItemUpdated}, {ID: jobs[2].ID(), Result: ItemCreated}}, nil
+	}}
+	i := newBulkIngester(repository, newMemoryIngestionStore(0, 0), alerter, 1, 10, 10, time.Hour, time.Minute)
+	if _, err := i.enqueue(context.TODO(), jobs); err != nil {
+		t.Fatalf("bulkIngester.enqueue() error = %v", err)
+	}
+	i.close()
+
+	if want := []Job{jobs[0], jobs[2]}; !reflect.DeepEqual(matched, want) {
+		t.Errorf("bulkIngester alerted jobs = %v, want %v", matched, want)
+	}
+}
+
+func createdResults(jobs []Job) []BulkItemResult {
+	result := make([]BulkItemResult, len(jobs))
+	for i, job := range jobs {
+		result[i] = BulkItemResult{ID: job.ID(), Result: ItemCreated}
+	}
+	return result
+}
@@ -2,86 +2,482 @@ package jobs
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"sync"
+
+	"github.com/bvieira/c-jobs/jobs/config"
 )
 
 // JobRepository access and update jobs data
 type JobRepository interface {
 	Add(ctx context.Context, job Job) error
-	Search(ctx context.Context, content string, city string, sortingAsc bool) ([]Job, error)
+	AddAll(ctx context.Context, jobs []Job) ([]BulkItemResult, error)
+	Delete(ctx context.Context, id string) error
+	DeleteAll(ctx context.Context, ids []string) ([]BulkItemResult, error)
+	DeleteByQuery(ctx context.Context, content string, city string, dryRun bool) (*DeleteByQueryResult, error)
+	Get(ctx context.Context, id string) (*JobHit, error)
+	Search(ctx context.Context, search JobSearch) (*JobSearchResult, error)
+	Suggest(ctx context.Context, field, prefix string, size int) ([]Suggestion, error)
+	UpdateMapping(ctx context.Context, mapping string) error
 }
 
 // Repository access and update any data
 type Repository interface {
 	InitIndex(ctx context.Context, name, mapping string) error
 	Add(ctx context.Context, index string, content Indexable) error
-	Search(ctx context.Context, index string, sort *Sort, queries ...Query) ([]json.RawMessage, error)
+	BulkAdd(ctx context.Context, index string, items []Indexable) ([]BulkItemResult, error)
+	Delete(ctx context.Context, index, typ, id string) error
+	BulkDelete(ctx context.Context, index, typ string, ids []string) ([]BulkItemResult, error)
+	Get(ctx context.Context, index, id string) (json.RawMessage, error)
+	Count(ctx context.Context, index string, queries ...Query) (int64, error)
+	DeleteByQuery(ctx context.Context, index string, queries ...Query) (*DeleteByQueryResult, error)
+	Search(ctx context.Context, index string, sorts []Sort, page *Page, facets []Facet, highlight *Highlight, queries ...Query) (*SearchResult, error)
+	Suggest(ctx context.Context, index, field, prefix string, size int) ([]Suggestion, error)
+	AddPercolator(ctx context.Context, index, id string, queries ...Query) error
+	Percolate(ctx context.Context, index string, docs []Indexable) ([][]string, error)
 }
 
-// ElasticSearchJobRepository JobRepository impl for elastic search
+// repository kinds available on JOBS_REPOSITORY
+const (
+	RepositoryElasticSearch = "elasticsearch"
+	RepositoryMemory        = "memory"
+	RepositoryDisk          = "disk"
+)
+
+// newRepository creates the repository of kind, elasticsearch connects on background and disk loads its indexes from JOBS_DISK_PATH
+func newRepository(kind string) (Repository, error) {
+	switch kind {
+	case RepositoryElasticSearch:
+		return newElasticSearch(config.Get().ElasticSearchServer, config.Get().ElasticSearchMaxRetry, config.Get().ElasticSearchSniff, config.Get().ElasticSearchReconnectRetryTime), nil
+	case RepositoryMemory:
+		return newInMemoryRepository(), nil
+	case RepositoryDisk:
+		disk, err := newDiskRepository(config.Get().DiskPath, config.Get().DiskCompactOperations)
+		if err != nil {
+			return nil, err
+		}
+		return disk, nil
+	}
+	return nil, fmt.Errorf("invalid repository: %s, use one of: %s, %s, %s", kind, RepositoryElasticSearch, RepositoryMemory, RepositoryDisk)
+}
+
+// ElasticSearchJobRepository JobRepository impl for elastic search, jobs are kept on the 'jobs' index of each tenant
 type ElasticSearchJobRepository struct {
 	repository  Repository
 	mapping     string
-	initialized bool
+	highlight   Highlight
+	initialized map[string]bool
 	rmutex      sync.RWMutex
 }
 
-// newElasticSearchJobRepository ElasticSearchJobRepository constructor
-func newElasticSearchJobRepository(elasticSearch Repository, mapping string) JobRepository {
-	return &ElasticSearchJobRepository{repository: elasticSearch, mapping: mapping}
+// newElasticSearchJobRepository ElasticSearchJobRepository constructor, highlight has the tags and fragments options used on search highlight
+func newElasticSearchJobRepository(elasticSearch Repository, mapping string, highlight Highlight) JobRepository {
+	return &ElasticSearchJobRepository{repository: elasticSearch, mapping: mapping, highlight: highlight, initialized: make(map[string]bool)}
 }
 
-func (r *ElasticSearchJobRepository) init(ctx context.Context) error {
+// index jobs index of the tenant on ctx, initialized on first use
+func (r *ElasticSearchJobRepository) index(ctx context.Context) (string, error) {
+	index := tenantIndex(ctx, "jobs")
 	r.rmutex.RLock()
-	initialized := r.initialized
+	initialized := r.initialized[index]
 	r.rmutex.RUnlock()
 
 	if !initialized {
 		r.rmutex.Lock()
 		defer r.rmutex.Unlock()
-		if err := r.repository.InitIndex(ctx, "jobs", r.mapping); err != nil {
-			return err
+		if r.initialized[index] { //initialized by another request while waiting
+			return index, nil
 		}
-		r.initialized = true
+		if err := r.repository.InitIndex(ctx, index, r.mapping); err != nil {
+			return "", err
+		}
+		r.initialized[index] = true
+	}
+	return index, nil
+}
+
+// UpdateMapping replaces index mapping, migrating jobs of the tenant on ctx to a new index when mapping changed,
+// indexes of other tenants are migrated on their next use
+func (r *ElasticSearchJobRepository) UpdateMapping(ctx context.Context, mapping string) error {
+	index := tenantIndex(ctx, "jobs")
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	if err := r.repository.InitIndex(ctx, index, mapping); err != nil {
+		return err
 	}
+	r.mapping = mapping
+	r.initialized = map[string]bool{index: true}
 	return nil
 }
 
 // Add adds jobs on repository
 func (r *ElasticSearchJobRepository) Add(ctx context.Context, job Job) error {
-	if err := r.init(ctx); err != nil { //lazy index initialization
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
 		return err
 	}
-	return r.repository.Add(ctx, "jobs", job)
+	return r.repository.Add(ctx, index, job)
 }
 
-// Search find jobs on repository
-func (r *ElasticSearchJobRepository) Search(ctx context.Context, content string, city string, sortingAsc bool) ([]Job, error) {
-	var queries []Query
-	if content != "" {
-		queries = append(queries, Query{Value: content, Fields: []string{"title^3", "description"}, Operator: "and"})
+// AddAll adds jobs on repository using a single bulk request, returns the result of each job
+func (r *ElasticSearchJobRepository) AddAll(ctx context.Context, jobs []Job) ([]BulkItemResult, error) {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Indexable, len(jobs))
+	for i, job := range jobs {
+		items[i] = job
+	}
+	return r.repository.BulkAdd(ctx, index, items)
+}
+
+// Delete removes job from repository by id
+func (r *ElasticSearchJobRepository) Delete(ctx context.Context, id string) error {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return err
+	}
+	return r.repository.Delete(ctx, index, indexType(Job{}), id)
+}
+
+// DeleteAll removes jobs from repository by id using a single bulk request, returns the result of each id
+func (r *ElasticSearchJobRepository) DeleteAll(ctx context.Context, ids []string) ([]BulkItemResult, error) {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	return r.repository.BulkDelete(ctx, index, indexType(Job{}), ids)
+}
+
+// DeleteByQuery removes all jobs matching content and city, on dry run only counts the jobs
+func (r *ElasticSearchJobRepository) DeleteByQuery(ctx context.Context, content string, city string, dryRun bool) (*DeleteByQueryResult, error) {
+	queries, err := createJobQueries(JobSearch{Content: content, City: city})
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		count, err := r.repository.Count(ctx, index, queries...)
+		if err != nil {
+			return nil, err
+		}
+		return &DeleteByQueryResult{Matched: count, DryRun: true}, nil
 	}
-	if city != "" {
-		queries = append(queries, Query{Value: city, Fields: []string{"cidade"}, Operator: "and"})
+	return r.repository.DeleteByQuery(ctx, index, queries...)
+}
+
+// Get finds job on repository by id
+func (r *ElasticSearchJobRepository) Get(ctx context.Context, id string) (*JobHit, error) {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
 	}
-	result, err := r.repository.Search(ctx, "jobs", &Sort{Field: "salario", Ascending: sortingAsc}, queries...)
+	result, err := r.repository.Get(ctx, index, id)
 	if err != nil {
 		return nil, err
 	}
-	return toJobs(result)
+	job, err := toJob(result)
+	if err != nil {
+		return nil, err
+	}
+	return &JobHit{ID: job.ID(), Job: job}, nil
 }
 
-func toJobs(searchResult []json.RawMessage) ([]Job, error) {
-	jobs := make([]Job, 0)
-	for _, r := range searchResult {
-		var job Job
-		perr := json.Unmarshal(r, &job)
-		if perr != nil {
-			return nil, NewParserError(fmt.Sprintf("error mapping search result to job, message: %s", perr.Error()))
+// Search find jobs on repository, sorted by search sort and paginated by page or cursor, missing page and size use the defaults
+func (r *ElasticSearchJobRepository) Search(ctx context.Context, search JobSearch) (*JobSearchResult, error) {
+	pageNumber, size := intValue(search.Page, 1), intValue(search.Size, DefaultPageSize)
+	page := &Page{From: (pageNumber - 1) * size, Size: size}
+	if search.Cursor != "" {
+		searchAfter, err := decodeCursor(search.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		page = &Page{Size: size, SearchAfter: searchAfter}
+	}
+
+	sorts, err := createJobSorts(search.Sort)
+	if err != nil {
+		return nil, err
+	}
+	facets, err := createJobFacets(search.Facets, search.Buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := createJobQueries(search)
+	if err != nil {
+		return nil, err
+	}
+	if search.SimilarTo != "" {
+		queries = append(queries, Query{Fields: []string{"title", "description"}, Like: &Like{Type: indexType(Job{}), IDs: []string{search.SimilarTo}}})
+	}
+	var highlight *Highlight
+	if search.Highlight {
+		highlight = &Highlight{Fields: jobHighlightFields, PreTag: r.highlight.PreTag, PostTag: r.highlight.PostTag, FragmentSize: r.highlight.FragmentSize, Fragments: r.highlight.Fragments}
+	}
+
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.repository.Search(ctx, index, sorts, page, facets, highlight, queries...)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := toJobHits(result.Hits, highlight)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &JobSearchResult{Total: result.Total, Page: pageNumber, Size: size, Jobs: jobs, Facets: result.Facets}
+	more := len(jobs) == size && len(result.LastSort) > 0
+	if search.Cursor == "" {
+		more = more && int64(page.From+len(jobs)) < result.Total
+	}
+	if more {
+		if response.Next, err = encodeCursor(result.LastSort); err != nil {
+			return nil, err
 		}
-		jobs = append(jobs, job)
+	}
+	return response, nil
+}
+
+// Suggest completes prefix of title or city, ignoring accents and case, duplicated suggestions are merged
+func (r *ElasticSearchJobRepository) Suggest(ctx context.Context, field, prefix string, size int) ([]Suggestion, error) {
+	suggestField, ok := jobSuggestFields[field]
+	if !ok {
+		return nil, NewInvalidRequestError(fmt.Sprintf("invalid suggest field: %s, use one of: %s, %s", field, SuggestTitle, SuggestCity))
+	}
+
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	//each job is a suggestion, asks for more to fill size after merging duplicates
+	result, err := r.repository.Suggest(ctx, index, suggestField, strings.ToLower(removeAccents(prefix)), size*suggestDuplicatesFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]Suggestion, 0)
+	seen := make(map[string]bool)
+	for _, suggestion := range result {
+		key := strings.ToLower(removeAccents(suggestion.Text))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, suggestion)
+		if len(suggestions) == size {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// job fields available on suggest
+const (
+	SuggestTitle            = "title"
+	SuggestCity             = "city"
+	suggestDuplicatesFactor = 5
+)
+
+var jobSuggestFields = map[string]string{
+	SuggestTitle: "title.suggest",
+	SuggestCity:  "cidade.suggest",
+}
+
+// createJobQueries creates queries for query language, content, city and salary range and filters for state and exact city
+func createJobQueries(search JobSearch) ([]Query, error) {
+	var queries []Query
+	if strings.TrimSpace(search.Query) != "" {
+		query, err := parseJobQuery(search.Query, search.Fuzziness)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, *query)
+	}
+	if search.Content != "" {
+		queries = append(queries, Query{Value: search.Content, Fields: []string{"title^3", "description"}, Operator: "and", Fuzziness: search.Fuzziness})
+	}
+	if search.City != "" {
+		queries = append(queries, Query{Value: search.City, Fields: []string{"cidade"}, Operator: "and", Fuzziness: search.Fuzziness})
+	}
+	if search.SalaryMin != nil || search.SalaryMax != nil {
+		queries = append(queries, Query{Range: &Range{Field: "salario", Min: search.SalaryMin, Max: search.SalaryMax}})
+	}
+	if search.State != "" {
+		queries = append(queries, Query{Term: &Term{Path: "locations", Field: "locations.state", Value: strings.ToUpper(search.State)}})
+	}
+	if search.CityExact != "" {
+		queries = append(queries, Query{Term: &Term{Path: "locations", Field: "locations.city", Value: search.CityExact}})
+	}
+	return queries, nil
+}
+
+// job sort options, SortRelevance sorts by score with salary as tie-breaker
+const (
+	SortRelevance = "relevance"
+	SortAsc       = "asc"
+	SortDesc      = "desc"
+)
+
+// jobSortFields sortable fields by name
+var jobSortFields = map[string]string{
+	"salary":         "salario",
+	"city":           "cidade.keyword",
+	"city_formatted": "cidadeFormated",
+	SortRelevance:    ScoreField,
+}
+
+// createJobSorts parses sort as 'asc', 'desc', 'relevance' or a list of 'field:dir', default: salary desc
+func createJobSorts(sort string) ([]Sort, error) {
+	switch strings.ToLower(strings.TrimSpace(sort)) {
+	case "", SortDesc:
+		return []Sort{{Field: "salario"}}, nil
+	case SortAsc:
+		return []Sort{{Field: "salario", Ascending: true}}, nil
+	case SortRelevance:
+		return []Sort{{Field: ScoreField}, {Field: "salario"}}, nil
+	}
+
+	var sorts []Sort
+	used := make(map[string]bool)
+	for _, option := range strings.Split(sort, ",") {
+		parts := strings.SplitN(strings.TrimSpace(option), ":", 2)
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		field, ok := jobSortFields[name]
+		if !ok {
+			return nil, NewInvalidRequestError(fmt.Sprintf("invalid sort field: %s, use one of: %s", name, strings.Join(jobSortFieldNames(), ", ")))
+		}
+		if used[name] {
+			return nil, NewInvalidRequestError(fmt.Sprintf("duplicated sort field: %s", name))
+		}
+		used[name] = true
+
+		direction := SortDesc
+		if len(parts) > 1 {
+			direction = strings.ToLower(strings.TrimSpace(parts[1]))
+		}
+		if direction != SortAsc && direction != SortDesc {
+			return nil, NewInvalidRequestError(fmt.Sprintf("invalid sort direction for %s: %s, use %s or %s", name, direction, SortAsc, SortDesc))
+		}
+		sorts = append(sorts, Sort{Field: field, Ascending: direction == SortAsc})
+	}
+	return sorts, nil
+}
+
+func jobSortFieldNames() []string {
+	return []string{"salary", "city", "city_formatted", SortRelevance}
+}
+
+// job facets available on search
+const (
+	FacetCity          = "city"
+	FacetCityFormatted = "city_formatted"
+	FacetSalary        = "salary"
+	FacetState         = "state"
+	facetStatesSize    = 30
+	facetTermsSize     = 20
+)
+
+func createJobFacets(names []string, salaryBuckets []float64) ([]Facet, error) {
+	var facets []Facet
+	for _, name := range names {
+		switch name {
+		case FacetCity:
+			facets = append(facets, Facet{Name: name, Field: "cidade.keyword", Size: facetTermsSize})
+		case FacetCityFormatted:
+			facets = append(facets, Facet{Name: name, Field: "cidadeFormated", Size: facetTermsSize})
+		case FacetState:
+			facets = append(facets, Facet{Name: name, Field: "locations.state", Size: facetStatesSize, Path: "locations", Sum: "locations.openings"})
+		case FacetSalary:
+			if len(salaryBuckets) == 0 {
+				return nil, NewInvalidRequestError("salary buckets is empty")
+			}
+			facets = append(facets, Facet{Name: name, Field: "salario", Edges: salaryBuckets})
+		default:
+			return nil, NewInvalidRequestError(fmt.Sprintf("invalid facet: %s, use one of: %s, %s, %s, %s", name, FacetCity, FacetCityFormatted, FacetSalary, FacetState))
+		}
+	}
+	return facets, nil
+}
+
+func toJob(source json.RawMessage) (Job, error) {
+	var job Job
+	if err := json.Unmarshal(source, &job); err != nil {
+		return job, NewParserError(fmt.Sprintf("error mapping search result to job, message: %s", err.Error()))
+	}
+	return job, nil
+}
+
+func toJobHits(hits []SearchHit, highlight *Highlight) ([]JobHit, error) {
+	jobs := make([]JobHit, 0)
+	for _, hit := range hits {
+		job, err := toJob(hit.Source)
+		if err != nil {
+			return nil, err
+		}
+		jobHit := JobHit{ID: hit.ID, Score: hit.Score, Job: job}
+		if highlight != nil && len(hit.Highlight) > 0 {
+			jobHit.Highlights = make(map[string][]string)
+			for field, fragments := range hit.Highlight {
+				for _, fragment := range fragments {
+					jobHit.Highlights[field] = append(jobHit.Highlights[field], cleanFragment(fragment, highlight.PreTag, highlight.PostTag))
+				}
+			}
+		}
+		jobs = append(jobs, jobHit)
 	}
 	return jobs, nil
 }
+
+// jobHighlightFields fields with highlight fragments on search
+var jobHighlightFields = []string{"title", "description"}
+
+var (
+	htmlTag        = regexp.MustCompile(`<[^>]*>`)
+	leadingCutTag  = regexp.MustCompile(`^[^\s<>]*>`)
+	trailingCutTag = regexp.MustCompile(`<([/!a-zA-Z][^<>]*)?$`)
+)
+
+// cleanFragment removes html markup from highlight fragment, keeping only highlight tags, fragments may cut tags on the edges.
+// only a '>' before any whitespace or a last '<' alone or followed by a tag name are cut tags, other '<' and '>' are text, eg. 'salário > 3000'
+func cleanFragment(fragment, preTag, postTag string) string {
+	const preMark, postMark = "\x00", "\x01"
+	fragment = strings.Replace(strings.Replace(fragment, preTag, preMark, -1), postTag, postMark, -1)
+	fragment = leadingCutTag.ReplaceAllString(fragment, "")
+	fragment = trailingCutTag.ReplaceAllString(fragment, "")
+	fragment = htmlTag.ReplaceAllString(fragment, " ")
+	fragment = strings.Join(strings.Fields(fragment), " ")
+	return strings.Replace(strings.Replace(fragment, preMark, preTag, -1), postMark, postTag, -1)
+}
+
+// encodeCursor encodes sort values of the last hit as an opaque cursor
+func encodeCursor(sortValues []interface{}) (string, error) {
+	b, err := json.Marshal(sortValues)
+	if err != nil {
+		return "", NewParserError(fmt.Sprintf("error creating cursor, message: %s", err.Error()))
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, NewInvalidRequestError(fmt.Sprintf("invalid cursor, message: %s", err.Error()))
+	}
+	var sortValues []interface{}
+	if err := json.Unmarshal(b, &sortValues); err != nil || len(sortValues) == 0 {
+		return nil, NewInvalidRequestError("invalid cursor")
+	}
+	return sortValues, nil
+}
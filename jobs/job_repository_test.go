@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -19,9 +20,9 @@ func TestElasticSearchJobRepository_Add(t *testing.T) {
 		args    args
 		wantErr bool
 	}{
-		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, ""), args{context.TODO(), Job{}}, true},
-		{"error on add", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, addFn: func() error { return errors.New("error on add") }}, ""), args{context.TODO(), Job{}}, true},
-		{"success", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, addFn: func() error { return nil }}, ""), args{context.TODO(), Job{}}, false},
+		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, "", Highlight{}), args{context.TODO(), Job{}}, true},
+		{"error on add", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, addFn: func() error { return errors.New("error on add") }}, "", Highlight{}), args{context.TODO(), Job{}}, true},
+		{"success", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, addFn: func() error { return nil }}, "", Highlight{}), args{context.TODO(), Job{}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -32,61 +33,377 @@ func TestElasticSearchJobRepository_Add(t *testing.T) {
 	}
 }
 
-func TestElasticSearchJobRepository_Search(t *testing.T) {
+func TestElasticSearchJobRepository_AddAll(t *testing.T) {
 	type args struct {
-		ctx        context.Context
-		content    string
-		city       string
-		sortingAsc bool
+		ctx  context.Context
+		jobs []Job
 	}
 	tests := []struct {
 		name    string
 		r       JobRepository
 		args    args
-		want    []Job
 		wantErr bool
 	}{
-		{"success", newElasticSearchJobRepository(&mockRepository{searchFn: func() ([]json.RawMessage, error) { return []json.RawMessage{}, nil }}, ""), args{context.TODO(), "aaa", "bbb", false}, []Job{}, false},
-		{"error", newElasticSearchJobRepository(&mockRepository{searchFn: func() ([]json.RawMessage, error) { return nil, errors.New("error") }}, ""), args{context.TODO(), "aaa", "", false}, nil, true},
+		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, "", Highlight{}), args{context.TODO(), []Job{Job{}}}, true},
+		{"error on bulk add", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, bulkAddFn: func() ([]BulkItemResult, error) { return nil, errors.New("error on bulk add") }}, "", Highlight{}), args{context.TODO(), []Job{Job{}}}, true},
+		{"success", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, bulkAddFn: func() ([]BulkItemResult, error) { return []BulkItemResult{}, nil }}, "", Highlight{}), args{context.TODO(), []Job{Job{}, Job{Title: "a"}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Search(tt.args.ctx, tt.args.content, tt.args.city, tt.args.sortingAsc)
+			if _, err := tt.r.AddAll(tt.args.ctx, tt.args.jobs); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.AddAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElasticSearchJobRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       JobRepository
+		wantErr bool
+	}{
+		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, "", Highlight{}), true},
+		{"error on delete", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, deleteFn: func() error { return NewNotFoundError("not found") }}, "", Highlight{}), true},
+		{"success", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, deleteFn: func() error { return nil }}, "", Highlight{}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Delete(context.TODO(), "id"); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElasticSearchJobRepository_DeleteAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       JobRepository
+		wantErr bool
+	}{
+		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, "", Highlight{}), true},
+		{"error on bulk delete", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, bulkDeleteFn: func() ([]BulkItemResult, error) { return nil, errors.New("error on bulk delete") }}, "", Highlight{}), true},
+		{"success", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return nil }, bulkDeleteFn: func() ([]BulkItemResult, error) { return []BulkItemResult{}, nil }}, "", Highlight{}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.r.DeleteAll(context.TODO(), []string{"id"}); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.DeleteAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElasticSearchJobRepository_DeleteByQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       JobRepository
+		dryRun  bool
+		want    *DeleteByQueryResult
+		wantErr bool
+	}{
+		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, "", Highlight{}), true, nil, true},
+		{"count error", newElasticSearchJobRepository(&mockRepository{countFn: func() (int64, error) { return 0, errors.New("error on count") }}, "", Highlight{}), true, nil, true},
+		{"dry run", newElasticSearchJobRepository(&mockRepository{countFn: func() (int64, error) { return 3, nil }}, "", Highlight{}), true, &DeleteByQueryResult{Matched: 3, DryRun: true}, false},
+		{"delete error", newElasticSearchJobRepository(&mockRepository{deleteByQueryFn: func() (*DeleteByQueryResult, error) { return nil, errors.New("error on delete") }}, "", Highlight{}), false, nil, true},
+		{"success", newElasticSearchJobRepository(&mockRepository{deleteByQueryFn: func() (*DeleteByQueryResult, error) { return &DeleteByQueryResult{Matched: 3, Deleted: 3}, nil }}, "", Highlight{}), false, &DeleteByQueryResult{Matched: 3, Deleted: 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.DeleteByQuery(context.TODO(), "aaa", "bbb", tt.dryRun)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ElasticSearchJobRepository.Search() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ElasticSearchJobRepository.DeleteByQuery() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("ElasticSearchJobRepository.Search() = %v, want %v", got, tt.want)
+				t.Errorf("ElasticSearchJobRepository.DeleteByQuery() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_toJobs(t *testing.T) {
+func Test_createJobQueries(t *testing.T) {
 	tests := []struct {
 		name    string
-		args    []json.RawMessage
-		want    []Job
+		search  JobSearch
+		want    []Query
 		wantErr bool
 	}{
-		{"success", []json.RawMessage{jobRawJSONExample()}, []Job{jobExample()}, false},
-		{"invalid json", []json.RawMessage{json.RawMessage([]byte("{"))}, nil, true},
+		{"empty", JobSearch{}, nil, false},
+		{"content", JobSearch{Content: "aaa"}, []Query{{Value: "aaa", Fields: []string{"title^3", "description"}, Operator: "and"}}, false},
+		{"content and city", JobSearch{Content: "aaa", City: "bbb"}, []Query{{Value: "aaa", Fields: []string{"title^3", "description"}, Operator: "and"}, {Value: "bbb", Fields: []string{"cidade"}, Operator: "and"}}, false},
+		{"salary range", JobSearch{SalaryMin: floatPtr(1000), SalaryMax: floatPtr(3000)}, []Query{{Range: &Range{Field: "salario", Min: floatPtr(1000), Max: floatPtr(3000)}}}, false},
+		{"content and salary min", JobSearch{Content: "aaa", SalaryMin: floatPtr(1000)}, []Query{{Value: "aaa", Fields: []string{"title^3", "description"}, Operator: "and"}, {Range: &Range{Field: "salario", Min: floatPtr(1000)}}}, false},
+		{"fuzzy content and city", JobSearch{Content: "aaa", City: "bbb", Fuzziness: "AUTO"}, []Query{{Value: "aaa", Fields: []string{"title^3", "description"}, Operator: "and", Fuzziness: "AUTO"}, {Value: "bbb", Fields: []string{"cidade"}, Operator: "and", Fuzziness: "AUTO"}}, false},
+		{"state and exact city", JobSearch{State: "sc", CityExact: "Joinville"}, []Query{{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}}, {Term: &Term{Path: "locations", Field: "locations.city", Value: "Joinville"}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := toJobs(tt.args)
+			got, err := createJobQueries(tt.search)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("toJobs() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("createJobQueries() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("toJobs() = %v, want %v", got, tt.want)
+				t.Errorf("createJobQueries() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestElasticSearchJobRepository_UpdateMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		initErr     error
+		wantMapping string
+		wantErr     bool
+	}{
+		{"error keeps mapping", errors.New("error"), "old", true},
+		{"success", nil, "new", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ElasticSearchJobRepository{repository: &mockRepository{initFn: func() error { return tt.initErr }}, mapping: "old"}
+			if err := r.UpdateMapping(context.TODO(), "new"); (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.UpdateMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if r.mapping != tt.wantMapping {
+				t.Errorf("ElasticSearchJobRepository.UpdateMapping() mapping = %v, want %v", r.mapping, tt.wantMapping)
+			}
+		})
+	}
+}
+
+func TestElasticSearchJobRepository_Suggest(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		prefix    string
+		size      int
+		result    []Suggestion
+		err       error
+		want      []Suggestion
+		wantQuery string
+		wantErr   bool
+	}{
+		{"invalid field", "description", "anal", 5, nil, nil, nil, "", true},
+		{"error", "title", "anal", 5, nil, errors.New("error"), nil, "title.suggest:anal:25", true},
+		{"accents and duplicates", "city", "Florián", 2, []Suggestion{{"Florianópolis", 2}, {"florianopolis", 2}, {"Floriano", 1}, {"Florianópolis", 1}}, nil, []Suggestion{{"Florianópolis", 2}, {"Floriano", 1}}, "cidade.suggest:florian:10", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotQuery string
+			r := newElasticSearchJobRepository(&mockRepository{suggestFn: func(field, prefix string, size int) ([]Suggestion, error) {
+				gotQuery = fmt.Sprintf("%s:%s:%d", field, prefix, size)
+				return tt.result, tt.err
+			}}, "", Highlight{})
+			got, err := r.Suggest(context.TODO(), tt.field, tt.prefix, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.Suggest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearchJobRepository.Suggest() = %v, want %v", got, tt.want)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("ElasticSearchJobRepository.Suggest() query = %v, want %v", gotQuery, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func Test_createJobSorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []Sort
+		wantErr bool
+	}{
+		{"default", "", []Sort{{Field: "salario"}}, false},
+		{"asc", "ASC", []Sort{{Field: "salario", Ascending: true}}, false},
+		{"desc", "desc", []Sort{{Field: "salario"}}, false},
+		{"relevance", "relevance", []Sort{{Field: ScoreField}, {Field: "salario"}}, false},
+		{"multiple fields", "city:asc, salary:desc,relevance", []Sort{{Field: "cidade.keyword", Ascending: true}, {Field: "salario"}, {Field: ScoreField}}, false},
+		{"invalid field", "title:asc", nil, true},
+		{"invalid direction", "salary:up", nil, true},
+		{"duplicated field", "salary:asc,salary:desc", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createJobSorts(tt.sort)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createJobSorts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createJobSorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createJobFacets(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		buckets []float64
+		want    []Facet
+		wantErr bool
+	}{
+		{"empty", nil, nil, nil, false},
+		{"all facets", []string{"city", "city_formatted", "salary", "state"}, []float64{1000}, []Facet{{Name: "city", Field: "cidade.keyword", Size: facetTermsSize}, {Name: "city_formatted", Field: "cidadeFormated", Size: facetTermsSize}, {Name: "salary", Field: "salario", Edges: []float64{1000}}, {Name: "state", Field: "locations.state", Size: facetStatesSize, Path: "locations", Sum: "locations.openings"}}, false},
+		{"salary without buckets", []string{"salary"}, nil, nil, true},
+		{"unknown facet", []string{"country"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createJobFacets(tt.names, tt.buckets)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createJobFacets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createJobFacets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearchJobRepository_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       JobRepository
+		want    *JobHit
+		wantErr bool
+	}{
+		{"index not initialized", newElasticSearchJobRepository(&mockRepository{initFn: func() error { return errors.New("index not initialized") }}, "", Highlight{}), nil, true},
+		{"not found", newElasticSearchJobRepository(&mockRepository{getFn: func() (json.RawMessage, error) { return nil, NewNotFoundError("not found") }}, "", Highlight{}), nil, true},
+		{"invalid json", newElasticSearchJobRepository(&mockRepository{getFn: func() (json.RawMessage, error) { return json.RawMessage([]byte("{")), nil }}, "", Highlight{}), nil, true},
+		{"success", newElasticSearchJobRepository(&mockRepository{getFn: func() (json.RawMessage, error) { return jobRawJSONExample(), nil }}, "", Highlight{}), &JobHit{ID: jobExample().ID(), Job: jobExample()}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Get(context.TODO(), "id")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearchJobRepository.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearchJobRepository_Search(t *testing.T) {
+	cursor, _ := encodeCursor([]interface{}{1500.0, "job#id"})
+	tests := []struct {
+		name     string
+		r        JobRepository
+		search   JobSearch
+		want     *JobSearchResult
+		wantPage *Page
+		wantErr  bool
+	}{
+		{"success", nil, JobSearch{Content: "aaa", City: "bbb", Page: intPtr(1), Size: intPtr(10)}, &JobSearchResult{Total: 0, Page: 1, Size: 10, Jobs: []JobHit{}}, &Page{From: 0, Size: 10}, false},
+		{"error", nil, JobSearch{Content: "aaa", Page: intPtr(1), Size: intPtr(10)}, nil, &Page{From: 0, Size: 10}, true},
+		{"next page", nil, JobSearch{Content: "aaa", Page: intPtr(2), Size: intPtr(1)}, &JobSearchResult{Total: 3, Page: 2, Size: 1, Next: cursor, Jobs: []JobHit{{ID: jobExample().ID(), Job: jobExample()}}}, &Page{From: 1, Size: 1}, false},
+		{"last page", nil, JobSearch{Content: "aaa", Page: intPtr(3), Size: intPtr(1)}, &JobSearchResult{Total: 3, Page: 3, Size: 1, Jobs: []JobHit{{ID: jobExample().ID(), Job: jobExample()}}}, &Page{From: 2, Size: 1}, false},
+		{"search after", nil, JobSearch{Content: "aaa", Page: intPtr(1), Size: intPtr(1), Cursor: cursor}, &JobSearchResult{Total: 3, Page: 1, Size: 1, Next: cursor, Jobs: []JobHit{{ID: jobExample().ID(), Job: jobExample()}}}, &Page{Size: 1, SearchAfter: []interface{}{1500.0, "job#id"}}, false},
+		{"invalid cursor", nil, JobSearch{Content: "aaa", Page: intPtr(1), Size: intPtr(1), Cursor: "!"}, nil, nil, true},
+		{"invalid facet", nil, JobSearch{Content: "aaa", Page: intPtr(1), Size: intPtr(1), Facets: []string{"unknown"}}, nil, nil, true},
+		{"invalid sort", nil, JobSearch{Content: "aaa", Page: intPtr(1), Size: intPtr(1), Sort: "unknown:asc"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPage *Page
+			r := newElasticSearchJobRepository(&mockRepository{searchFn: func(page *Page) (*SearchResult, error) {
+				gotPage = page
+				switch {
+				case tt.wantErr:
+					return nil, errors.New("error")
+				case tt.want.Total == 0:
+					return &SearchResult{}, nil
+				}
+				return &SearchResult{Total: tt.want.Total, Hits: []SearchHit{{ID: jobExample().ID(), Source: jobRawJSONExample()}}, LastSort: []interface{}{1500.0, "job#id"}}, nil
+			}}, "", Highlight{})
+			got, err := r.Search(context.TODO(), tt.search)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchJobRepository.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearchJobRepository.Search() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotPage, tt.wantPage) {
+				t.Errorf("ElasticSearchJobRepository.Search() page = %v, want %v", gotPage, tt.wantPage)
+			}
+		})
+	}
+}
+
+func Test_toJobHits(t *testing.T) {
+	score := 1.5
+	highlight := &Highlight{PreTag: "<em>", PostTag: "</em>"}
+	tests := []struct {
+		name      string
+		hits      []SearchHit
+		highlight *Highlight
+		want      []JobHit
+		wantErr   bool
+	}{
+		{"success", []SearchHit{{ID: "id1", Score: &score, Source: jobRawJSONExample()}}, nil, []JobHit{{ID: "id1", Score: &score, Job: jobExample()}}, false},
+		{"highlights", []SearchHit{{ID: "id1", Source: jobRawJSONExample(), Highlight: map[string][]string{"title": {"<em>Estagio</em> de Auxiliar"}, "description": {"li> Deverá estar <em>cursando</em>: Ensino</li><li> Auxiliar <"}}}}, highlight, []JobHit{{ID: "id1", Highlights: map[string][]string{"title": {"<em>Estagio</em> de Auxiliar"}, "description": {"Deverá estar <em>cursando</em>: Ensino Auxiliar"}}, Job: jobExample()}}, false},
+		{"highlights not requested", []SearchHit{{ID: "id1", Source: jobRawJSONExample(), Highlight: map[string][]string{"title": {"<em>Estagio</em>"}}}}, nil, []JobHit{{ID: "id1", Job: jobExample()}}, false},
+		{"invalid json", []SearchHit{{ID: "id1", Source: json.RawMessage([]byte("{"))}}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toJobHits(tt.hits, tt.highlight)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("toJobHits() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toJobHits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cleanFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		preTag   string
+		postTag  string
+		want     string
+	}{
+		{"plain text", "analista de <em>sistemas</em>", "<em>", "</em>", "analista de <em>sistemas</em>"},
+		{"markup", "<li> Conhecimento em <em>Linux</em></li><li>Windows</li>", "<em>", "</em>", "Conhecimento em <em>Linux</em> Windows"},
+		{"cut tags on edges", "i> <b>Linux</b> server</l", "<b>", "</b>", "<b>Linux</b> server"},
+		{"custom tags", "<li>[[Linux]]</li>", "[[", "]]", "[[Linux]]"},
+		{"greater than on text", "salário > 3000 <em>java</em>", "<em>", "</em>", "salário > 3000 <em>java</em>"},
+		{"less than on text", "<em>java</em> salário < 3000", "<em>", "</em>", "<em>java</em> salário < 3000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanFragment(tt.fragment, tt.preTag, tt.postTag); got != tt.want {
+				t.Errorf("cleanFragment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
 func jobRawJSONExample() json.RawMessage {
 	body := `{"title":"Assistente de Contabilidade","description":"<li> Realizar classificação, conciliação e lançamento contábil e participar na apuração de impostos e preenchimento de guias de recolhimento junto aos órgãos do governo. Controlar escrituração de livros fiscais e auxiliar na elaboração de balancetes e demonstrativos de contabilidade.</li>","salario":1500,"cidade":["Canoas"],"cidadeFormated":["Canoas - RS (1)"]}`
 	return json.RawMessage([]byte(body))
@@ -98,17 +415,65 @@ func jobExample() Job {
 }
 
 type mockRepository struct {
-	initFn   func() error
-	addFn    func() error
-	searchFn func() ([]json.RawMessage, error)
+	initFn          func() error
+	addFn           func() error
+	bulkAddFn       func() ([]BulkItemResult, error)
+	deleteFn        func() error
+	bulkDeleteFn    func() ([]BulkItemResult, error)
+	getFn           func() (json.RawMessage, error)
+	countFn         func() (int64, error)
+	deleteByQueryFn func() (*DeleteByQueryResult, error)
+	searchFn        func(page *Page) (*SearchResult, error)
+	suggestFn       func(field, prefix string, size int) ([]Suggestion, error)
+	addPercolatorFn func(id string, queries []Query) error
+	percolateFn     func(docs []Indexable) ([][]string, error)
 }
 
 func (r mockRepository) InitIndex(ctx context.Context, name, mapping string) error {
+	if r.initFn == nil {
+		return nil
+	}
 	return r.initFn()
 }
 func (r mockRepository) Add(ctx context.Context, index string, content Indexable) error {
 	return r.addFn()
 }
-func (r mockRepository) Search(ctx context.Context, index string, sort *Sort, queries ...Query) ([]json.RawMessage, error) {
-	return r.searchFn()
+func (r mockRepository) BulkAdd(ctx context.Context, index string, items []Indexable) ([]BulkItemResult, error) {
+	return r.bulkAddFn()
+}
+func (r mockRepository) Delete(ctx context.Context, index, typ, id string) error {
+	return r.deleteFn()
+}
+func (r mockRepository) BulkDelete(ctx context.Context, index, typ string, ids []string) ([]BulkItemResult, error) {
+	return r.bulkDeleteFn()
+}
+func (r mockRepository) Get(ctx context.Context, index, id string) (json.RawMessage, error) {
+	return r.getFn()
+}
+func (r mockRepository) Count(ctx context.Context, index string, queries ...Query) (int64, error) {
+	return r.countFn()
+}
+func (r mockRepository) DeleteByQuery(ctx context.Context, index string, queries ...Query) (*DeleteByQueryResult, error) {
+	return r.deleteByQueryFn()
+}
+func (r mockRepository) Search(ctx context.Context, index string, sorts []Sort, page *Page, facets []Facet, highlight *Highlight, queries ...Query) (*SearchResult, error) {
+	return r.searchFn(page)
+}
+func (r mockRepository) Suggest(ctx context.Context, index, field, prefix string, size int) ([]Suggestion, error) {
+	return r.suggestFn(field, prefix, size)
+}
+func (r mockRepository) AddPercolator(ctx context.Context, index, id string, queries ...Query) error {
+	return r.addPercolatorFn(id, queries)
+}
+func (r mockRepository) Percolate(ctx context.Context, index string, docs []Indexable) ([][]string, error) {
+	return r.percolateFn(docs)
+}
+
+func Test_newRepository(t *testing.T) {
+	if got, err := newRepository(RepositoryMemory); err != nil || reflect.TypeOf(got) != reflect.TypeOf(&InMemoryRepository{}) {
+		t.Errorf("newRepository() = %T, %v, want *InMemoryRepository", got, err)
+	}
+	if _, err := newRepository("redis"); err == nil || err.Error() != "invalid repository: redis, use one of: elasticsearch, memory, disk" {
+		t.Errorf("newRepository() error = %v, want invalid repository", err)
+	}
 }
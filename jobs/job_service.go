@@ -3,43 +3,393 @@ package jobs
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bvieira/c-jobs/jobs/config"
 )
 
 // JobsService job services, process job info
 type JobsService struct {
-	repository JobRepository
+	backend      Repository
+	repository   JobRepository
+	searches     SearchRepository
+	alerts       AlertRepository
+	ingester     *bulkIngester
+	ingestions   IngestionStore
+	buckets      []float64
+	apiKeys      *APIKeyStore
+	jwt          *JWTValidator
+	tenants      map[string]bool
+	mappingPath  string
+	synonymsPath string
 }
 
 // NewJobServices contructor for default configuration
 func NewJobServices() *JobsService {
-	mapping, err := ioutil.ReadFile(config.Get().ElasticSearchIndexMappingPath)
-	if err != nil || len(mapping) == 0 {
-		panic(fmt.Errorf("could not load config on path: %v, could be missing or empty, error: %v", config.Get().ElasticSearchIndexMappingPath, err))
+	mapping, err := loadMapping(config.Get().ElasticSearchIndexMappingPath, config.Get().ElasticSearchSynonymsPath)
+	if err != nil {
+		panic(err)
 	}
 
+	highlight := Highlight{PreTag: config.Get().SearchHighlightPreTag, PostTag: config.Get().SearchHighlightPostTag, FragmentSize: config.Get().SearchHighlightFragmentSize, Fragments: config.Get().SearchHighlightFragments}
+	backend, err := newRepository(config.Get().Repository)
+	if err != nil {
+		panic(err)
+	}
+	repository := newElasticSearchJobRepository(backend, mapping, highlight)
+	buckets, err := ParseBuckets(config.Get().SearchSalaryBuckets)
+	if err != nil {
+		panic(fmt.Errorf("could not parse salary buckets: %v, error: %v", config.Get().SearchSalaryBuckets, err))
+	}
+
+	alerts := newElasticSearchAlertRepository(backend, mapping)
+	sink, err := newAlertSink(config.Get().AlertSink, config.Get().AlertWebhookURL, config.Get().AlertWebhookSecret, time.Duration(config.Get().AlertWebhookTimeout)*time.Millisecond)
+	if err != nil {
+		panic(fmt.Errorf("could not create alert sink: %v, error: %v", config.Get().AlertSink, err))
+	}
+	var alerter *jobAlerter
+	if sink != nil {
+		alerter = newJobAlerter(alerts, sink, config.Get().AlertQueueSize, config.Get().AlertRetries, time.Duration(config.Get().AlertRetryWait)*time.Millisecond)
+	}
+
+	apiKeys, err := LoadAPIKeys(config.Get().APIKeysPath, config.Get().APIKeys)
+	if err != nil {
+		panic(fmt.Errorf("could not load api keys, error: %v", err))
+	}
+	jwt, err := LoadJWTValidator(config.Get().JWTSecret, config.Get().JWTJWKSPath, config.Get().JWTAudience, config.Get().JWTIssuer, time.Duration(config.Get().JWTLeeway)*time.Second)
+	if err != nil {
+		panic(fmt.Errorf("could not load jwt validator, error: %v", err))
+	}
+	tenants, err := ParseTenants(config.Get().Tenants)
+	if err != nil {
+		panic(fmt.Errorf("could not parse tenants: %v, error: %v", config.Get().Tenants, err))
+	}
+	if len(apiKeys.keys) == 0 && jwt == nil {
+		log.Print("message=\"no api keys or jwt configured, write endpoints reject every request\" kind=startup")
+	}
+
+	ingestions := newMemoryIngestionStore(time.Duration(config.Get().IngestionRetention)*time.Minute, config.Get().IngestionMaxTracked)
 	return &JobsService{
-		repository: newElasticSearchJobRepository(newElasticSearch(config.Get().ElasticSearchServer, config.Get().ElasticSearchMaxRetry, config.Get().ElasticSearchSniff, config.Get().ElasticSearchReconnectRetryTime), string(mapping)),
+		backend:      backend,
+		repository:   repository,
+		searches:     newElasticSearchSearchRepository(backend),
+		alerts:       alerts,
+		ingestions:   ingestions,
+		buckets:      buckets,
+		apiKeys:      apiKeys,
+		jwt:          jwt,
+		tenants:      tenants,
+		mappingPath:  config.Get().ElasticSearchIndexMappingPath,
+		synonymsPath: config.Get().ElasticSearchSynonymsPath,
+		ingester:     newBulkIngester(repository, ingestions, alerter, config.Get().IngestionWorkers, config.Get().IngestionBatchSize, config.Get().IngestionQueueSize, time.Duration(config.Get().IngestionFlushInterval)*time.Millisecond, time.Duration(config.Get().IngestionFlushTimeout)*time.Second),
 	}
 }
 
+// search page size limits
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+	maxResultWindow = 10000
+)
+
 // Search searches on repository for jobs with content, city sorted by salary
-func (s JobsService) Search(ctx context.Context, content string, city string, sortingAsc bool) ([]Job, error) {
-	return s.repository.Search(ctx, content, city, sortingAsc)
+func (s JobsService) Search(ctx context.Context, search JobSearch) (*JobSearchResult, error) {
+	page, size := intValue(search.Page, 1), intValue(search.Size, DefaultPageSize)
+	switch {
+	case page < 1:
+		return nil, NewInvalidRequestError("page must be greater than 0")
+	case size < 1 || size > MaxPageSize:
+		return nil, NewInvalidRequestError(fmt.Sprintf("size must be between 1 and %d", MaxPageSize))
+	case search.Cursor != "" && page > 1:
+		return nil, NewInvalidRequestError("page and search_after cannot be used together")
+	case page*size > maxResultWindow:
+		return nil, NewInvalidRequestError(fmt.Sprintf("page * size must be at most %d, use search_after for deep paging", maxResultWindow))
+	}
+	search.Page, search.Size = &page, &size
+	if err := validateCriteria(search); err != nil {
+		return nil, err
+	}
+	if strings.ToLower(search.Fuzziness) == "auto" {
+		search.Fuzziness = "AUTO"
+	}
+	if len(search.Buckets) == 0 {
+		search.Buckets = s.buckets
+	}
+	return s.repository.Search(ctx, search)
 }
 
-// Add index jobs on repository
-func (s JobsService) Add(ctx context.Context, jobs []Job) error {
+// suggest size limits
+const (
+	DefaultSuggestSize = 5
+	MaxSuggestSize     = 20
+)
+
+// Suggest completes prefix of job title or city
+func (s JobsService) Suggest(ctx context.Context, field, prefix string, size *int) ([]Suggestion, error) {
+	n := intValue(size, DefaultSuggestSize)
+	switch {
+	case strings.TrimSpace(prefix) == "":
+		return nil, NewInvalidRequestError("prefix should not be empty")
+	case n < 1 || n > MaxSuggestSize:
+		return nil, NewInvalidRequestError(fmt.Sprintf("size must be between 1 and %d", MaxSuggestSize))
+	}
+	return s.repository.Suggest(ctx, field, strings.TrimSpace(prefix), n)
+}
+
+// intValue value of i, def when i is nil
+func intValue(i *int, def int) int {
+	if i == nil {
+		return def
+	}
+	return *i
+}
+
+// validateCriteria validates search filters that are not validated on repository
+func validateCriteria(search JobSearch) error {
+	switch {
+	case search.SalaryMin != nil && *search.SalaryMin < 0, search.SalaryMax != nil && *search.SalaryMax < 0:
+		return NewInvalidRequestError("salary_min and salary_max must not be negative")
+	case search.SalaryMin != nil && search.SalaryMax != nil && *search.SalaryMin > *search.SalaryMax:
+		return NewInvalidRequestError("salary_min must not be greater than salary_max")
+	case search.State != "" && len(search.State) != 2:
+		return NewInvalidRequestError(fmt.Sprintf("invalid state: %s, use the 2 letters abbreviation, eg. SC", search.State))
+	case !validFuzziness[strings.ToLower(search.Fuzziness)]:
+		return NewInvalidRequestError(fmt.Sprintf("invalid fuzzy: %s, use auto, 0, 1 or 2", search.Fuzziness))
+	}
+	return nil
+}
+
+// validFuzziness fuzzy values accepted on search, empty disables fuzzy matching
+var validFuzziness = map[string]bool{"": true, "auto": true, "0": true, "1": true, "2": true}
+
+// ParseBuckets parses comma separated bucket edges, edges must be in ascending order
+func ParseBuckets(value string) ([]float64, error) {
+	var buckets []float64
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		edge, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, NewInvalidRequestError(fmt.Sprintf("invalid bucket edge: %s", v))
+		}
+		if len(buckets) > 0 && edge <= buckets[len(buckets)-1] {
+			return nil, NewInvalidRequestError("bucket edges must be in ascending order")
+		}
+		buckets = append(buckets, edge)
+	}
+	return buckets, nil
+}
+
+// Similar searches for jobs similar to job with id on title and description, sorted by relevance by default
+func (s JobsService) Similar(ctx context.Context, id string, search JobSearch) (*JobSearchResult, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if search.Content != "" {
+		return nil, NewInvalidRequestError("content cannot be used on similar jobs")
+	}
+	if search.Sort == "" {
+		search.Sort = SortRelevance
+	}
+	search.SimilarTo = id
+	return s.Search(ctx, search)
+}
+
+// MaxSavedSearchNameLength max length of a saved search name
+const MaxSavedSearchNameLength = 100
+
+// SaveSearch validates and stores search criteria with a new id and registers it to alert new matching jobs, returns the saved search
+func (s JobsService) SaveSearch(ctx context.Context, search SavedSearch) (*SavedSearch, error) {
+	search.Name = strings.TrimSpace(search.Name)
+	switch {
+	case search.Name == "":
+		return nil, NewInvalidRequestError("name should not be empty")
+	case len([]rune(search.Name)) > MaxSavedSearchNameLength:
+		return nil, NewInvalidRequestError(fmt.Sprintf("name must have at most %d characters", MaxSavedSearchNameLength))
+	}
+
+	criteria := search.JobSearch()
+	if len(criteria.Buckets) == 0 {
+		criteria.Buckets = s.buckets
+	}
+	if err := validateCriteria(criteria); err != nil {
+		return nil, err
+	}
+	queries, err := createJobQueries(criteria)
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, NewInvalidRequestError("search criteria should not be empty")
+	}
+	if _, err := createJobSorts(criteria.Sort); err != nil {
+		return nil, err
+	}
+	if _, err := createJobFacets(criteria.Facets, criteria.Buckets); err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(search.Fuzziness) == "auto" {
+		search.Fuzziness = "AUTO"
+	}
+	search.ID = newRandomID()
+	search.CreatedAt = time.Now().UTC()
+	if err := s.searches.Add(ctx, search); err != nil {
+		return nil, err
+	}
+	if err := s.alerts.Register(ctx, search); err != nil {
+		if deleteErr := s.searches.Delete(ctx, search.ID); deleteErr != nil {
+			log.Printf("message=\"error removing saved search without alert\" kind=alert search=%s error=\"%s\"", search.ID, deleteErr.Error())
+		}
+		return nil, err
+	}
+	return &search, nil
+}
+
+// SavedSearchResults executes saved search with id, only pagination, cursor and highlight are used from search
+func (s JobsService) SavedSearchResults(ctx context.Context, id string, search JobSearch) (*JobSearchResult, error) {
+	saved, err := s.searches.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	criteria := saved.JobSearch()
+	criteria.Page = search.Page
+	criteria.Size = search.Size
+	criteria.Cursor = search.Cursor
+	criteria.Highlight = search.Highlight
+	return s.Search(ctx, criteria)
+}
+
+// ListSearches returns a page of saved searches, newest first
+func (s JobsService) ListSearches(ctx context.Context, page, size *int) (*SavedSearchList, error) {
+	p, n := intValue(page, 1), intValue(size, DefaultPageSize)
+	switch {
+	case p < 1:
+		return nil, NewInvalidRequestError("page must be greater than 0")
+	case n < 1 || n > MaxPageSize:
+		return nil, NewInvalidRequestError(fmt.Sprintf("size must be between 1 and %d", MaxPageSize))
+	case p*n > maxResultWindow:
+		return nil, NewInvalidRequestError(fmt.Sprintf("page * size must be at most %d", maxResultWindow))
+	}
+	return s.searches.List(ctx, p, n)
+}
+
+// DeleteSearch removes saved search by id and its alert
+func (s JobsService) DeleteSearch(ctx context.Context, id string) error {
+	if id == "" {
+		return NewInvalidRequestError("id is empty")
+	}
+	if err := s.searches.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.alerts.Unregister(ctx, id)
+}
+
+// Get finds job by id
+func (s JobsService) Get(ctx context.Context, id string) (*JobHit, error) {
+	if id == "" {
+		return nil, NewInvalidRequestError("id is empty")
+	}
+	return s.repository.Get(ctx, id)
+}
+
+// Add enqueues jobs to be indexed asynchronously on repository with locations parsed from cidadeFormated, returns the ingestion id
+func (s JobsService) Add(ctx context.Context, jobs []Job) (string, error) {
+	if len(jobs) <= 0 {
+		return "", NewInvalidRequestError("jobs is empty")
+	}
+	parsed := make([]Job, len(jobs))
+	for i, job := range jobs {
+		job.Locations = parseLocations(job.CityFormatted)
+		parsed[i] = job
+	}
+	return s.ingester.enqueue(ctx, parsed)
+}
+
+// Delete removes job by id
+func (s JobsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return NewInvalidRequestError("id is empty")
+	}
+	return s.repository.Delete(ctx, id)
+}
+
+// DeleteAll removes jobs using the id derived from each job, returns the result of each job
+func (s JobsService) DeleteAll(ctx context.Context, jobs []Job) ([]DocumentResult, error) {
 	if len(jobs) <= 0 {
-		return NewInvalidRequestError("jobs is empty")
+		return nil, NewInvalidRequestError("jobs is empty")
 	}
 
-	for _, job := range jobs {
-		if err := s.repository.Add(ctx, job); err != nil {
-			return err
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID()
+	}
+	results, err := s.repository.DeleteAll(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]DocumentResult, len(results))
+	for i, r := range results {
+		docs[i] = DocumentResult{ID: r.ID, Result: r.Result}
+		if r.Error != nil {
+			docs[i].Error = r.Error.ErrCode
+		}
+	}
+	return docs, nil
+}
+
+// DeleteByQuery removes all jobs matching content and city, on dry run only counts the jobs
+func (s JobsService) DeleteByQuery(ctx context.Context, content string, city string, dryRun bool) (*DeleteByQueryResult, error) {
+	if content == "" && city == "" {
+		return nil, NewInvalidRequestError("content or city is required")
+	}
+	return s.repository.DeleteByQuery(ctx, content, city, dryRun)
+}
+
+// Ingestion returns the status of an ingestion and the result of each document
+func (s JobsService) Ingestion(ctx context.Context, id string) (*Ingestion, error) {
+	return s.ingestions.Get(ctx, id)
+}
+
+// ReloadSynonyms reads mapping and synonyms files again and rolls jobs and alerts indexes with the new synonyms
+func (s JobsService) ReloadSynonyms(ctx context.Context) error {
+	mapping, err := loadMapping(s.mappingPath, s.synonymsPath)
+	if err != nil {
+		return NewInvalidRequestError(err.Error())
+	}
+	if err := s.repository.UpdateMapping(ctx, mapping); err != nil {
+		return err
+	}
+	return s.alerts.UpdateMapping(ctx, mapping)
+}
+
+// AuthorizeAPIKey finds the api key allowed to scope, see APIKeyStore.Authorize
+func (s JobsService) AuthorizeAPIKey(ctx context.Context, key, scope string) (*APIKey, error) {
+	return s.apiKeys.Authorize(ctx, key, scope)
+}
+
+// AuthorizeToken validates the bearer token allowed to scope, see JWTValidator.Authorize
+func (s JobsService) AuthorizeToken(ctx context.Context, token, scope string) (*Claims, error) {
+	return s.jwt.Authorize(ctx, token, scope)
+}
+
+// ResolveTenant tenant of a request with a credential of tenant asking for requested tenant, see resolveTenant
+func (s JobsService) ResolveTenant(ctx context.Context, credential, requested string) (string, error) {
+	return resolveTenant(s.tenants, credential, requested)
+}
+
+// Close waits for all enqueued jobs to be indexed and alerted, then closes the repository when it holds resources as the disk repository files
+func (s JobsService) Close() {
+	s.ingester.close()
+	if closer, ok := s.backend.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("message=\"error closing repository\" kind=repository error=\"%s\"", err.Error())
 		}
 	}
-	return nil
 }
@@ -4,35 +4,355 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestJobsService_Search(t *testing.T) {
-	type args struct {
-		ctx        context.Context
-		content    string
-		city       string
-		sortingAsc bool
+	tests := []struct {
+		name       string
+		s          JobsService
+		search     JobSearch
+		want       *JobSearchResult
+		wantSearch JobSearch
+		wantErr    bool
+	}{
+		{"error", JobsService{repository: &mockJobRepository{searchFn: func(JobSearch) (*JobSearchResult, error) { return nil, errors.New("error on search") }}}, JobSearch{Content: "a", City: "b", Sort: "asc"}, nil, JobSearch{}, true},
+		{"invalid page", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Page: intPtr(-1)}, nil, JobSearch{}, true},
+		{"invalid size", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Size: intPtr(MaxPageSize + 1)}, nil, JobSearch{}, true},
+		{"page zero", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Page: intPtr(0)}, nil, JobSearch{}, true},
+		{"size zero", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Size: intPtr(0)}, nil, JobSearch{}, true},
+		{"negative salary", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", SalaryMin: floatPtr(-1)}, nil, JobSearch{}, true},
+		{"salary min greater than max", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", SalaryMin: floatPtr(3000), SalaryMax: floatPtr(1000)}, nil, JobSearch{}, true},
+		{"page with cursor", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Page: intPtr(2), Cursor: "abc"}, nil, JobSearch{}, true},
+		{"over result window", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Page: intPtr(1001), Size: intPtr(10)}, nil, JobSearch{}, true},
+		{"success with defaults", JobsService{repository: &mockJobRepository{searchFn: func(JobSearch) (*JobSearchResult, error) { return &JobSearchResult{Jobs: []JobHit{JobHit{}}}, nil }}, buckets: []float64{1000}}, JobSearch{Content: "a", City: "b"}, &JobSearchResult{Jobs: []JobHit{JobHit{}}}, JobSearch{Content: "a", City: "b", Page: intPtr(1), Size: intPtr(DefaultPageSize), Buckets: []float64{1000}}, false},
+		{"invalid state", JobsService{repository: &mockJobRepository{}}, JobSearch{State: "SCC"}, nil, JobSearch{}, true},
+		{"invalid fuzzy", JobsService{repository: &mockJobRepository{}}, JobSearch{Content: "a", Fuzziness: "3"}, nil, JobSearch{}, true},
+		{"success with fuzzy auto", JobsService{repository: &mockJobRepository{searchFn: func(JobSearch) (*JobSearchResult, error) { return &JobSearchResult{}, nil }}}, JobSearch{Content: "a", Fuzziness: "Auto"}, &JobSearchResult{}, JobSearch{Content: "a", Page: intPtr(1), Size: intPtr(DefaultPageSize), Fuzziness: "AUTO"}, false},
+		{"success with buckets", JobsService{repository: &mockJobRepository{searchFn: func(JobSearch) (*JobSearchResult, error) { return &JobSearchResult{}, nil }}, buckets: []float64{1000}}, JobSearch{Content: "a", Buckets: []float64{500}}, &JobSearchResult{}, JobSearch{Content: "a", Page: intPtr(1), Size: intPtr(DefaultPageSize), Buckets: []float64{500}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSearch JobSearch
+			if r, ok := tt.s.repository.(*mockJobRepository); ok && r.searchFn != nil {
+				searchFn := r.searchFn
+				r.searchFn = func(search JobSearch) (*JobSearchResult, error) {
+					gotSearch = search
+					return searchFn(search)
+				}
+			}
+			got, err := tt.s.Search(context.TODO(), tt.search)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JobsService.Search() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(gotSearch, tt.wantSearch) {
+				t.Errorf("JobsService.Search() repository search = %v, want %v", gotSearch, tt.wantSearch)
+			}
+		})
+	}
+}
+
+func TestParseBuckets(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []float64
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"success", "1000, 2500.5,5000", []float64{1000, 2500.5, 5000}, false},
+		{"invalid number", "1000,abc", nil, true},
+		{"not ascending", "2000,1000", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBuckets(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBuckets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBuckets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobsService_Suggest(t *testing.T) {
+	repository := &mockJobRepository{suggestFn: func(field, prefix string, size int) ([]Suggestion, error) {
+		return []Suggestion{{Text: field + ":" + prefix, Score: float64(size)}}, nil
+	}}
+	tests := []struct {
+		name    string
+		prefix  string
+		size    *int
+		want    []Suggestion
+		wantErr bool
+	}{
+		{"empty prefix error", " ", nil, nil, true},
+		{"invalid size error", "anal", intPtr(MaxSuggestSize + 1), nil, true},
+		{"size zero error", "anal", intPtr(0), nil, true},
+		{"success with defaults", " anal ", nil, []Suggestion{{Text: "title:anal", Score: DefaultSuggestSize}}, false},
+		{"success", "anal", intPtr(10), []Suggestion{{Text: "title:anal", Score: 10}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JobsService{repository: repository}.Suggest(context.TODO(), "title", tt.prefix, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.Suggest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JobsService.Suggest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobsService_ReloadSynonyms(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           JobsService
+		wantMapping string
+		wantErr     bool
+	}{
+		{"invalid mapping error", JobsService{mappingPath: "missing.json"}, "", true},
+		{"repository error", JobsService{mappingPath: "../cfg/jobs-mapping.json", synonymsPath: "../cfg/jobs-synonyms.txt"}, "", true},
+		{"success", JobsService{mappingPath: "../cfg/jobs-mapping.json", synonymsPath: "../cfg/jobs-synonyms.txt"}, "dev, desenvolvedor, programador", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMapping string
+			tt.s.repository = &mockJobRepository{updateMappingFn: func(mapping string) error {
+				gotMapping = mapping
+				if tt.wantErr {
+					return NewElasticsearchAccessError("error")
+				}
+				return nil
+			}}
+			var gotAlertsMapping string
+			tt.s.alerts = &mockAlertRepository{updateMappingFn: func(mapping string) error {
+				gotAlertsMapping = mapping
+				return nil
+			}}
+			if err := tt.s.ReloadSynonyms(context.TODO()); (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.ReloadSynonyms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.Contains(gotMapping, tt.wantMapping) {
+				t.Errorf("JobsService.ReloadSynonyms() mapping = %v, want synonyms %v", gotMapping, tt.wantMapping)
+			}
+			if !tt.wantErr && gotAlertsMapping != gotMapping {
+				t.Errorf("JobsService.ReloadSynonyms() alerts mapping = %v, want %v", gotAlertsMapping, gotMapping)
+			}
+		})
+	}
+}
+
+func TestJobsService_Similar(t *testing.T) {
+	found := func() (*JobHit, error) { return &JobHit{ID: "id"}, nil }
+	tests := []struct {
+		name       string
+		getFn      func() (*JobHit, error)
+		id         string
+		search     JobSearch
+		wantSearch JobSearch
+		wantErr    bool
+	}{
+		{"no id error", found, "", JobSearch{}, JobSearch{}, true},
+		{"not found error", func() (*JobHit, error) { return nil, NewNotFoundError("not found") }, "id", JobSearch{}, JobSearch{}, true},
+		{"content error", found, "id", JobSearch{Content: "a"}, JobSearch{}, true},
+		{"success with defaults", found, "id", JobSearch{City: "b"}, JobSearch{City: "b", SimilarTo: "id", Sort: SortRelevance, Page: intPtr(1), Size: intPtr(DefaultPageSize)}, false},
+		{"success with sort", found, "id", JobSearch{Sort: "salary:asc", Size: intPtr(5)}, JobSearch{SimilarTo: "id", Sort: "salary:asc", Page: intPtr(1), Size: intPtr(5)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSearch JobSearch
+			s := JobsService{repository: &mockJobRepository{getFn: tt.getFn, searchFn: func(search JobSearch) (*JobSearchResult, error) {
+				gotSearch = search
+				return &JobSearchResult{}, nil
+			}}}
+			_, err := s.Similar(context.TODO(), tt.id, tt.search)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.Similar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotSearch, tt.wantSearch) {
+				t.Errorf("JobsService.Similar() search = %+v, want %+v", gotSearch, tt.wantSearch)
+			}
+		})
+	}
+}
+
+func TestJobsService_SaveSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		search  SavedSearch
+		wantErr bool
+	}{
+		{"empty name", SavedSearch{Name: "  ", Content: "java"}, true},
+		{"long name", SavedSearch{Name: strings.Repeat("a", MaxSavedSearchNameLength+1)}, true},
+		{"no criteria", SavedSearch{Name: "java", Sort: SortRelevance, Facets: []string{FacetSalary}}, true},
+		{"invalid state", SavedSearch{Name: "java", State: "SCC"}, true},
+		{"invalid query", SavedSearch{Name: "java", Query: "title:(java"}, true},
+		{"invalid sort", SavedSearch{Name: "java", Sort: "title:asc"}, true},
+		{"invalid facet", SavedSearch{Name: "java", Facets: []string{"title"}}, true},
+		{"register error", SavedSearch{Name: "java jobs", Content: "fail"}, true},
+		{"success", SavedSearch{ID: "ignored", Name: " java jobs ", Query: "title:java", Facets: []string{FacetSalary}, Sort: SortRelevance, Fuzziness: "auto"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var registered *SavedSearch
+			s := JobsService{searches: newMemorySearchRepository(), buckets: []float64{1000}, alerts: &mockAlertRepository{registerFn: func(search SavedSearch) error {
+				if search.Content == "fail" {
+					return NewElasticsearchAccessError("error")
+				}
+				registered = &search
+				return nil
+			}}}
+			got, err := s.SaveSearch(context.TODO(), tt.search)
+			if list, _ := s.searches.List(context.TODO(), 1, 10); tt.wantErr && list.Total != 0 {
+				t.Errorf("JobsService.SaveSearch() stored %d searches on error", list.Total)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.SaveSearch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ID == "" || got.ID == tt.search.ID || got.Name != "java jobs" || got.Fuzziness != "AUTO" || got.CreatedAt.IsZero() {
+				t.Errorf("JobsService.SaveSearch() = %+v", got)
+			}
+			if stored, err := s.searches.Get(context.TODO(), got.ID); err != nil || !reflect.DeepEqual(stored, got) {
+				t.Errorf("JobsService.SaveSearch() stored = %+v, %v, want %+v", stored, err, got)
+			}
+			if !reflect.DeepEqual(registered, got) {
+				t.Errorf("JobsService.SaveSearch() registered = %+v, want %+v", registered, got)
+			}
+		})
+	}
+}
+
+func TestJobsService_SavedSearchResults(t *testing.T) {
+	searches := newMemorySearchRepository()
+	searches.Add(context.TODO(), SavedSearch{ID: "abc", Name: "java", Query: "title:java", City: "Joinville", SalaryMin: floatPtr(3000), Sort: SortAsc})
+	tests := []struct {
+		name       string
+		id         string
+		search     JobSearch
+		wantSearch JobSearch
+		wantErr    bool
+	}{
+		{"not found", "def", JobSearch{}, JobSearch{}, true},
+		{"success with defaults", "abc", JobSearch{}, JobSearch{Query: "title:java", City: "Joinville", SalaryMin: floatPtr(3000), Sort: SortAsc, Page: intPtr(1), Size: intPtr(DefaultPageSize)}, false},
+		{"criteria from request are ignored", "abc", JobSearch{Content: "golang", Sort: SortDesc, Page: intPtr(2), Size: intPtr(5), Highlight: true}, JobSearch{Query: "title:java", City: "Joinville", SalaryMin: floatPtr(3000), Sort: SortAsc, Page: intPtr(2), Size: intPtr(5), Highlight: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSearch JobSearch
+			s := JobsService{searches: searches, repository: &mockJobRepository{searchFn: func(search JobSearch) (*JobSearchResult, error) {
+				gotSearch = search
+				return &JobSearchResult{}, nil
+			}}}
+			_, err := s.SavedSearchResults(context.TODO(), tt.id, tt.search)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.SavedSearchResults() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotSearch, tt.wantSearch) {
+				t.Errorf("JobsService.SavedSearchResults() search = %+v, want %+v", gotSearch, tt.wantSearch)
+			}
+		})
+	}
+}
+
+func TestJobsService_ListSearches(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     *int
+		size     *int
+		wantPage int
+		wantSize int
+		wantErr  bool
+	}{
+		{"invalid page", intPtr(-1), nil, 0, 0, true},
+		{"page zero", intPtr(0), nil, 0, 0, true},
+		{"invalid size", intPtr(1), intPtr(MaxPageSize + 1), 0, 0, true},
+		{"over result window", intPtr(1001), intPtr(10), 0, 0, true},
+		{"success with defaults", nil, nil, 1, DefaultPageSize, false},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := JobsService{searches: newMemorySearchRepository()}
+			got, err := s.ListSearches(context.TODO(), tt.page, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.ListSearches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.Page != tt.wantPage || got.Size != tt.wantSize) {
+				t.Errorf("JobsService.ListSearches() = %+v, want page %d size %d", got, tt.wantPage, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestJobsService_DeleteSearch(t *testing.T) {
+	searches := newMemorySearchRepository()
+	searches.Add(context.TODO(), SavedSearch{ID: "abc", Name: "java"})
+	tests := []struct {
+		name           string
+		id             string
+		wantUnregister string
+		wantErr        bool
+	}{
+		{"no id error", "", "", true},
+		{"not found error", "def", "", true},
+		{"success", "abc", "abc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUnregister string
+			s := JobsService{searches: searches, alerts: &mockAlertRepository{unregisterFn: func(id string) error {
+				gotUnregister = id
+				return nil
+			}}}
+			if err := s.DeleteSearch(context.TODO(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.DeleteSearch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotUnregister != tt.wantUnregister {
+				t.Errorf("JobsService.DeleteSearch() unregister = %v, want %v", gotUnregister, tt.wantUnregister)
+			}
+		})
+	}
+}
+
+func TestJobsService_Get(t *testing.T) {
 	tests := []struct {
 		name    string
 		s       JobsService
-		args    args
-		want    []Job
+		id      string
+		want    *JobHit
 		wantErr bool
 	}{
-		{"error", JobsService{&mockJobRepository{searchFn: func() ([]Job, error) { return nil, errors.New("error on search") }}}, args{context.TODO(), "a", "b", true}, nil, true},
-		{"success", JobsService{&mockJobRepository{searchFn: func() ([]Job, error) { return []Job{Job{}}, nil }}}, args{context.TODO(), "a", "b", false}, []Job{Job{}}, false},
+		{"no id error", JobsService{repository: &mockJobRepository{}}, "", nil, true},
+		{"not found error", JobsService{repository: &mockJobRepository{getFn: func() (*JobHit, error) { return nil, NewNotFoundError("not found") }}}, "id", nil, true},
+		{"success", JobsService{repository: &mockJobRepository{getFn: func() (*JobHit, error) { return &JobHit{ID: "id"}, nil }}}, "id", &JobHit{ID: "id"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.s.Search(tt.args.ctx, tt.args.content, tt.args.city, tt.args.sortingAsc)
+			got, err := tt.s.Get(context.TODO(), tt.id)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("JobsService.Search() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("JobsService.Get() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("JobsService.Search() = %v, want %v", got, tt.want)
+				t.Errorf("JobsService.Get() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -49,27 +369,203 @@ func TestJobsService_Add(t *testing.T) {
 		args    args
 		wantErr bool
 	}{
-		{"no jobs error", JobsService{&mockJobRepository{}}, args{context.TODO(), nil}, true},
-		{"add error", JobsService{&mockJobRepository{addFn: func() error { return errors.New("error on add") }}}, args{context.TODO(), []Job{Job{}}}, true},
-		{"success", JobsService{&mockJobRepository{addFn: func() error { return nil }}}, args{context.TODO(), []Job{Job{}}}, false},
+		{"no jobs error", JobsService{repository: &mockJobRepository{}}, args{context.TODO(), nil}, true},
+		{"canceled request error", JobsService{ingester: &bulkIngester{store: newMemoryIngestionStore(0, 0), queue: make(chan ingestionItem)}}, args{canceledContext(), []Job{Job{}}}, true},
+		{"success", JobsService{ingester: &bulkIngester{store: newMemoryIngestionStore(0, 0), queue: make(chan ingestionItem, 1)}}, args{context.TODO(), []Job{Job{}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.s.Add(tt.args.ctx, tt.args.jobs); (err != nil) != tt.wantErr {
+			got, err := tt.s.Add(tt.args.ctx, tt.args.jobs)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("JobsService.Add() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got == "" {
+				t.Errorf("JobsService.Add() returned empty ingestion id")
+			}
+		})
+	}
+}
+
+func TestJobsService_Add_canceled(t *testing.T) {
+	store := newMemoryIngestionStore(0, 0)
+	s := JobsService{ingester: &bulkIngester{store: store, queue: make(chan ingestionItem, 1)}}
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	id, err := s.Add(ctx, []Job{Job{Title: "a"}, Job{Title: "b"}})
+	if err != nil || id == "" {
+		t.Fatalf("JobsService.Add() partially enqueued = %v, %v, want ingestion id", id, err)
+	}
+	got, err := store.Get(context.TODO(), id)
+	if err != nil {
+		t.Fatalf("MemoryIngestionStore.Get() error = %v", err)
+	}
+	if got.Pending != 1 || got.Failed != 1 || got.Documents[1].Result != ItemFailed {
+		t.Errorf("JobsService.Add() ingestion = %+v, want first job pending and second failed", got)
+	}
+}
+
+func TestJobsService_Add_locations(t *testing.T) {
+	queue := make(chan ingestionItem, 1)
+	s := JobsService{ingester: &bulkIngester{store: newMemoryIngestionStore(0, 0), queue: queue}}
+	jobs := []Job{Job{Title: "a", CityFormatted: []string{"Joinville - SC (2)"}}}
+	if _, err := s.Add(context.TODO(), jobs); err != nil {
+		t.Fatalf("JobsService.Add() error = %v", err)
+	}
+
+	want := []Location{{City: "Joinville", State: "SC", Openings: 2}}
+	if got := (<-queue).job.Locations; !reflect.DeepEqual(got, want) {
+		t.Errorf("JobsService.Add() locations = %v, want %v", got, want)
+	}
+	if jobs[0].Locations != nil {
+		t.Errorf("JobsService.Add() changed request jobs")
+	}
+}
+
+func TestJobsService_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       JobsService
+		id      string
+		wantErr bool
+	}{
+		{"no id error", JobsService{repository: &mockJobRepository{}}, "", true},
+		{"delete error", JobsService{repository: &mockJobRepository{deleteFn: func() error { return NewNotFoundError("not found") }}}, "id", true},
+		{"success", JobsService{repository: &mockJobRepository{deleteFn: func() error { return nil }}}, "id", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.Delete(context.TODO(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestJobsService_DeleteAll(t *testing.T) {
+	job := Job{Title: "a"}
+	tests := []struct {
+		name    string
+		s       JobsService
+		jobs    []Job
+		want    []DocumentResult
+		wantErr bool
+	}{
+		{"no jobs error", JobsService{repository: &mockJobRepository{}}, nil, nil, true},
+		{"delete error", JobsService{repository: &mockJobRepository{deleteAllFn: func(ids []string) ([]BulkItemResult, error) { return nil, errors.New("error on delete") }}}, []Job{job}, nil, true},
+		{"success", JobsService{repository: &mockJobRepository{deleteAllFn: func(ids []string) ([]BulkItemResult, error) {
+			return []BulkItemResult{{ID: ids[0], Result: ItemDeleted}, {ID: ids[1], Result: ItemFailed, Error: NewNotFoundError("not found")}}, nil
+		}}}, []Job{job, Job{Title: "b"}}, []DocumentResult{{job.ID(), ItemDeleted, ""}, {Job{Title: "b"}.ID(), ItemFailed, JOB1002}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.DeleteAll(context.TODO(), tt.jobs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.DeleteAll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JobsService.DeleteAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobsService_DeleteByQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       JobsService
+		content string
+		want    *DeleteByQueryResult
+		wantErr bool
+	}{
+		{"no query error", JobsService{repository: &mockJobRepository{}}, "", nil, true},
+		{"delete error", JobsService{repository: &mockJobRepository{deleteByQueryFn: func() (*DeleteByQueryResult, error) { return nil, errors.New("error on delete") }}}, "a", nil, true},
+		{"success", JobsService{repository: &mockJobRepository{deleteByQueryFn: func() (*DeleteByQueryResult, error) { return &DeleteByQueryResult{Matched: 1, Deleted: 1}, nil }}}, "a", &DeleteByQueryResult{Matched: 1, Deleted: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.DeleteByQuery(context.TODO(), tt.content, "", false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.DeleteByQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JobsService.DeleteByQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobsService_Ingestion(t *testing.T) {
+	store := newMemoryIngestionStore(0, 0)
+	store.Create(context.TODO(), "ingestion1", []string{"doc1"})
+	tests := []struct {
+		name    string
+		s       JobsService
+		id      string
+		wantErr bool
+	}{
+		{"not found", JobsService{ingestions: store}, "unknown", true},
+		{"success", JobsService{ingestions: store}, "ingestion1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.Ingestion(context.TODO(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JobsService.Ingestion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.ID != tt.id {
+				t.Errorf("JobsService.Ingestion() = %v, want id %v", got, tt.id)
+			}
+		})
+	}
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	return ctx
+}
+
 type mockJobRepository struct {
-	addFn    func() error
-	searchFn func() ([]Job, error)
+	addFn           func() error
+	addAllFn        func(jobs []Job) ([]BulkItemResult, error)
+	deleteFn        func() error
+	deleteAllFn     func(ids []string) ([]BulkItemResult, error)
+	deleteByQueryFn func() (*DeleteByQueryResult, error)
+	getFn           func() (*JobHit, error)
+	searchFn        func(search JobSearch) (*JobSearchResult, error)
+	suggestFn       func(field, prefix string, size int) ([]Suggestion, error)
+	updateMappingFn func(mapping string) error
 }
 
 func (r mockJobRepository) Add(ctx context.Context, job Job) error {
 	return r.addFn()
 }
-func (r mockJobRepository) Search(ctx context.Context, content string, city string, sortingAsc bool) ([]Job, error) {
-	return r.searchFn()
+func (r mockJobRepository) AddAll(ctx context.Context, jobs []Job) ([]BulkItemResult, error) {
+	return r.addAllFn(jobs)
+}
+func (r mockJobRepository) Delete(ctx context.Context, id string) error {
+	return r.deleteFn()
+}
+func (r mockJobRepository) DeleteAll(ctx context.Context, ids []string) ([]BulkItemResult, error) {
+	return r.deleteAllFn(ids)
+}
+func (r mockJobRepository) DeleteByQuery(ctx context.Context, content string, city string, dryRun bool) (*DeleteByQueryResult, error) {
+	return r.deleteByQueryFn()
+}
+func (r mockJobRepository) Get(ctx context.Context, id string) (*JobHit, error) {
+	return r.getFn()
+}
+func (r mockJobRepository) Search(ctx context.Context, search JobSearch) (*JobSearchResult, error) {
+	return r.searchFn(search)
+}
+func (r mockJobRepository) Suggest(ctx context.Context, field, prefix string, size int) ([]Suggestion, error) {
+	return r.suggestFn(field, prefix, size)
+}
+func (r mockJobRepository) UpdateMapping(ctx context.Context, mapping string) error {
+	return r.updateMappingFn(mapping)
 }
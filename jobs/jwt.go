@@ -0,0 +1,362 @@
+package jobs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 used by RS256, ES256 and HS256
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// jwtAlgorithms hash of each supported jws algorithm, 'none' is never accepted
+var jwtAlgorithms = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// minJWTSecretSize min size in bytes of the hmac secret
+const minJWTSecretSize = 32
+
+// Claims validated claims of a bearer token, Tenant is empty when the token is not restricted to a tenant
+type Claims struct {
+	Subject string
+	Tenant  string
+	Scopes  []string
+}
+
+// HasScope checks if claims have scope or admin scope
+func (c Claims) HasScope(scope string) bool {
+	return hasScope(c.Scopes, scope)
+}
+
+// jwtKey verification key of a jwks entry, alg is empty when the key does not restrict the algorithm
+type jwtKey struct {
+	alg string
+	key interface{}
+}
+
+// JWTValidator validates bearer tokens signed with the hmac secret or with the keys of a jwks file,
+// tokens must have 'exp', 'sub', the configured 'iss' and 'aud' and may have 'nbf'
+type JWTValidator struct {
+	secret   []byte
+	keys     map[string]jwtKey
+	audience string
+	issuer   string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// LoadJWTValidator creates the validator of tokens signed with secret or with the keys of the jwks file on jwksPath, nil when both are empty
+func LoadJWTValidator(secret, jwksPath, audience, issuer string, leeway time.Duration) (*JWTValidator, error) {
+	if secret == "" && jwksPath == "" {
+		return nil, nil
+	}
+	if audience == "" || issuer == "" {
+		return nil, fmt.Errorf("audience and issuer are required to validate bearer tokens")
+	}
+	if secret != "" && len(secret) < minJWTSecretSize {
+		return nil, fmt.Errorf("jwt secret must have at least %d bytes", minJWTSecretSize)
+	}
+
+	v := &JWTValidator{audience: audience, issuer: issuer, leeway: leeway, now: time.Now}
+	if secret != "" {
+		v.secret = []byte(secret)
+	}
+	if jwksPath != "" {
+		b, err := ioutil.ReadFile(jwksPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading jwks file %s, message: %s", jwksPath, err.Error())
+		}
+		if v.keys, err = parseJWKS(b); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// jsonWebKey jwks entry, see rfc 7517
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// parseJWKS parses rsa, ec and oct signature keys of a jwks by kid, encryption keys are ignored
+func parseJWKS(content []byte) (map[string]jwtKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid jwks, message: %s", err.Error())
+	}
+
+	keys := make(map[string]jwtKey)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if _, ok := keys[k.Kid]; ok {
+			return nil, fmt.Errorf("duplicated jwks kid: '%s'", k.Kid)
+		}
+		if _, ok := jwtAlgorithms[k.Alg]; k.Alg != "" && (!ok || !strings.HasPrefix(k.Alg, jwkAlgorithmPrefix(k.Kty))) {
+			return nil, fmt.Errorf("invalid jwks alg %s for key %s", k.Alg, k.Kid)
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks key %s, message: %s", k.Kid, err.Error())
+		}
+		keys[k.Kid] = jwtKey{alg: k.Alg, key: key}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks without signature keys")
+	}
+	return keys, nil
+}
+
+// jwkAlgorithmPrefix prefix of the algorithms of key type kty
+func jwkAlgorithmPrefix(kty string) string {
+	switch kty {
+	case "RSA":
+		return "RS"
+	case "EC":
+		return "ES"
+	case "oct":
+		return "HS"
+	}
+	return kty
+}
+
+// publicKey verification key, *rsa.PublicKey, *ecdsa.PublicKey or []byte secret
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("rsa keys must have at least 2048 bits")
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, ok := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve: '%s'", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, err
+		}
+		if len(secret) < minJWTSecretSize {
+			return nil, fmt.Errorf("oct keys must have at least %d bytes", minJWTSecretSize)
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unsupported kty: '%s'", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid base64url value: '%s'", value)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// jwtHeader jose header of the token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims registered claims of the token, the scope claim, a space separated string or a list, and the tenant claim
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Tenant    string          `json:"tenant"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     json.RawMessage `json:"scope"`
+}
+
+// Authorize validates the signature and claims of token and checks scope, unauthorized when the token is missing or invalid and forbidden without scope
+func (v *JWTValidator) Authorize(ctx context.Context, token, scope string) (*Claims, error) {
+	if token == "" {
+		return nil, NewUnauthorizedError("bearer token is required")
+	}
+	if v == nil {
+		return nil, NewUnauthorizedError("bearer tokens are not enabled")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, NewUnauthorizedError("invalid bearer token")
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if err := v.verify(header, parts[0]+"."+parts[1], parts[2]); err != nil {
+		return nil, err
+	}
+
+	var c jwtClaims
+	if err := decodeJWTPart(parts[1], &c); err != nil {
+		return nil, err
+	}
+	now, leeway := float64(v.now().UnixNano())/float64(time.Second), v.leeway.Seconds()
+	switch {
+	case c.ExpiresAt == nil:
+		return nil, NewUnauthorizedError("token without exp")
+	case now > *c.ExpiresAt+leeway:
+		return nil, NewUnauthorizedError("token expired")
+	case c.NotBefore != nil && now+leeway < *c.NotBefore:
+		return nil, NewUnauthorizedError("token not valid yet")
+	case c.Issuer != v.issuer:
+		return nil, NewUnauthorizedError(fmt.Sprintf("invalid token issuer: '%s'", c.Issuer))
+	case !containsClaim(c.Audience, v.audience):
+		return nil, NewUnauthorizedError("invalid token audience")
+	case c.Subject == "":
+		return nil, NewUnauthorizedError("token without sub")
+	case c.Tenant != DefaultTenant && !tenantName.MatchString(c.Tenant):
+		return nil, NewUnauthorizedError(fmt.Sprintf("invalid token tenant: '%s'", c.Tenant))
+	}
+
+	claims := &Claims{Subject: c.Subject, Tenant: c.Tenant, Scopes: claimValues(c.Scope)}
+	if !claims.HasScope(scope) {
+		return nil, NewForbiddenError(fmt.Sprintf("subject %s has no scope %s", claims.Subject, scope))
+	}
+	return claims, nil
+}
+
+// verify checks the signature of the token with the key of kid or with the secret when token has no kid
+func (v *JWTValidator) verify(header jwtHeader, signed, signature string) *JobError {
+	hash, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return NewUnauthorizedError(fmt.Sprintf("unsupported token alg: '%s'", header.Alg))
+	}
+	key, ok := v.key(header.Kid)
+	if !ok {
+		return NewUnauthorizedError(fmt.Sprintf("unknown token kid: '%s'", header.Kid))
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return NewUnauthorizedError(fmt.Sprintf("token alg %s does not match key alg %s", header.Alg, key.alg))
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return NewUnauthorizedError("invalid bearer token signature")
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	valid := false
+	switch k := key.key.(type) {
+	case []byte:
+		if strings.HasPrefix(header.Alg, "HS") {
+			mac := hmac.New(hash.New, k)
+			mac.Write([]byte(signed))
+			valid = hmac.Equal(sig, mac.Sum(nil))
+		}
+	case *rsa.PublicKey:
+		valid = strings.HasPrefix(header.Alg, "RS") && rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if strings.HasPrefix(header.Alg, "ES") && len(sig) == 2*size {
+			r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+			valid = ecdsa.Verify(k, digest, r, s)
+		}
+	}
+	if !valid {
+		return NewUnauthorizedError("invalid bearer token signature")
+	}
+	return nil
+}
+
+// key verification key of kid, the secret is used for tokens without kid
+func (v *JWTValidator) key(kid string) (jwtKey, bool) {
+	if kid == "" && v.secret != nil {
+		return jwtKey{key: v.secret}, true
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+func decodeJWTPart(part string, v interface{}) *JobError {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return NewUnauthorizedError("invalid bearer token encoding")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return NewUnauthorizedError("invalid bearer token json")
+	}
+	return nil
+}
+
+// claimValues values of a claim with a space separated string or a list of strings
+func claimValues(raw json.RawMessage) []string {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return strings.Fields(value)
+	}
+	var values []string
+	json.Unmarshal(raw, &values)
+	return values
+}
+
+// containsClaim checks if a string or list claim has value
+func containsClaim(raw json.RawMessage, value string) bool {
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var single string
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return false
+		}
+		values = []string{single}
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
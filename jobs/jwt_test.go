@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+var (
+	testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	testECKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testJWTNow    = time.Date(2017, 1, 26, 2, 10, 0, 0, time.UTC)
+)
+
+// signTestJWT signs claims with alg, using testJWTSecret for HS256, testRSAKey for RS256 and testECKey for ES256
+func signTestJWT(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, []byte(testJWTSecret))
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, testECKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "none":
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func testJWKS() string {
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	return fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa","alg":"RS256","use":"sig","n":"%s","e":"%s"},
+		{"kty":"EC","kid":"ec","crv":"P-256","x":"%s","y":"%s"},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}
+	]}`, b64(testRSAKey.N.Bytes()), b64(big.NewInt(int64(testRSAKey.E)).Bytes()), b64(testECKey.X.FillBytes(make([]byte, 32))), b64(testECKey.Y.FillBytes(make([]byte, 32))))
+}
+
+func newTestJWTValidator(t *testing.T) *JWTValidator {
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jwks.json")
+	ioutil.WriteFile(path, []byte(testJWKS()), 0600)
+
+	v, err := LoadJWTValidator(testJWTSecret, path, "c-jobs", "gateway", 30*time.Second)
+	if err != nil {
+		t.Fatalf("LoadJWTValidator() error = %v", err)
+	}
+	v.now = func() time.Time { return testJWTNow }
+	return v
+}
+
+func TestJWTValidator_Authorize(t *testing.T) {
+	v := newTestJWTValidator(t)
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "ingester", "iss": "gateway", "aud": "c-jobs", "exp": testJWTNow.Unix() + 60, "scope": "jobs:write jobs:delete"}
+		for k, value := range changes {
+			if value == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = value
+		}
+		return c
+	}
+	tests := []struct {
+		name      string
+		token     string
+		scope     string
+		wantError ErrorType
+	}{
+		{"hmac secret", signTestJWT(t, "HS256", "", claims(nil)), ScopeJobsWrite, 0},
+		{"rsa jwks key", signTestJWT(t, "RS256", "rsa", claims(nil)), ScopeJobsDelete, 0},
+		{"ec jwks key", signTestJWT(t, "ES256", "ec", claims(nil)), ScopeJobsWrite, 0},
+		{"audience list and scope list", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"aud": []string{"other", "c-jobs"}, "scope": []string{"jobs:write"}})), ScopeJobsWrite, 0},
+		{"expired within leeway", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"exp": testJWTNow.Unix() - 10})), ScopeJobsWrite, 0},
+		{"admin has every scope", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"scope": "admin"})), ScopeJobsDelete, 0},
+		{"missing token", "", ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"malformed token", "abc.def", ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"expired", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"exp": testJWTNow.Unix() - 60})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"missing exp", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"exp": nil})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"not valid yet", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"nbf": testJWTNow.Unix() + 60})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"invalid issuer", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"iss": "other"})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"invalid audience", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"aud": []string{"other"}})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"missing subject", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"sub": nil})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"invalid signature", signTestJWT(t, "HS256", "", claims(nil)) + "x", ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"alg none", signTestJWT(t, "none", "", claims(nil)), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"hmac with rsa key", signTestJWT(t, "HS256", "rsa", claims(nil)), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"unknown kid", signTestJWT(t, "RS256", "unknown", claims(nil)), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"encryption key", signTestJWT(t, "RS256", "enc", claims(nil)), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"invalid tenant", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"tenant": "Acme"})), ScopeJobsWrite, ERROR_UNAUTHORIZED},
+		{"missing scope", signTestJWT(t, "HS256", "", claims(map[string]interface{}{"scope": "jobs:write"})), ScopeJobsDelete, ERROR_FORBIDDEN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Authorize(context.TODO(), tt.token, tt.scope)
+			if tt.wantError != 0 {
+				if jobErr, ok := err.(*JobError); !ok || jobErr.ErrType != tt.wantError || jobErr.ErrCode != JOB1004 {
+					t.Errorf("JWTValidator.Authorize() error = %v, want type %d", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil || got.Subject != "ingester" {
+				t.Errorf("JWTValidator.Authorize() = %v, %v, want ingester", got, err)
+			}
+		})
+	}
+
+	if got, err := v.Authorize(context.TODO(), signTestJWT(t, "HS256", "", claims(map[string]interface{}{"tenant": "acme"})), ScopeJobsWrite); err != nil || got.Tenant != "acme" {
+		t.Errorf("JWTValidator.Authorize() = %v, %v, want tenant acme", got, err)
+	}
+
+	var disabled *JWTValidator
+	if _, err := disabled.Authorize(context.TODO(), signTestJWT(t, "HS256", "", claims(nil)), ScopeJobsWrite); err == nil {
+		t.Errorf("JWTValidator.Authorize() without validator error = nil, want unauthorized")
+	}
+}
+
+func TestLoadJWTValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		audience string
+		issuer   string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{"disabled", "", "", "", true, false},
+		{"secret", testJWTSecret, "c-jobs", "gateway", false, false},
+		{"missing audience", testJWTSecret, "", "gateway", false, true},
+		{"missing issuer", testJWTSecret, "c-jobs", "", false, true},
+		{"short secret", "secret", "c-jobs", "gateway", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadJWTValidator(tt.secret, "", tt.audience, tt.issuer, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadJWTValidator() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got == nil) != tt.wantNil {
+				t.Errorf("LoadJWTValidator() = %v, want nil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_parseJWKS(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantKeys int
+		wantErr  bool
+	}{
+		{"rsa and ec keys", testJWKS(), 2, false},
+		{"oct key", `{"keys":[{"kty":"oct","kid":"a","alg":"HS256","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"}]}`, 1, false},
+		{"invalid json", `{"keys":`, 0, true},
+		{"without signature keys", `{"keys":[]}`, 0, true},
+		{"short rsa key", `{"keys":[{"kty":"RSA","kid":"a","n":"AQAB","e":"AQAB"}]}`, 0, true},
+		{"short oct key", `{"keys":[{"kty":"oct","kid":"a","k":"c2VjcmV0"}]}`, 0, true},
+		{"alg of other kty", `{"keys":[{"kty":"oct","kid":"a","alg":"RS256","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"}]}`, 0, true},
+		{"unsupported curve", `{"keys":[{"kty":"EC","kid":"a","crv":"P-192","x":"AQ","y":"AQ"}]}`, 0, true},
+		{"point not on curve", `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"AQ","y":"AQ"}]}`, 0, true},
+		{"duplicated kid", `{"keys":[{"kty":"oct","kid":"a","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},{"kty":"oct","kid":"a","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"}]}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWKS([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseJWKS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantKeys {
+				t.Errorf("parseJWKS() = %d keys, want %d", len(got), tt.wantKeys)
+			}
+		})
+	}
+}
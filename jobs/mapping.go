@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// synonymsFilter name of the synonym filter on mapping analysis settings filled with synonyms file rules
+const synonymsFilter = "job_synonyms"
+
+// loadMapping reads index mapping and adds synonyms rules to the synonym filter, missing synonyms file means no synonyms
+func loadMapping(mappingPath, synonymsPath string) (string, error) {
+	mapping, err := ioutil.ReadFile(mappingPath)
+	if err != nil || len(mapping) == 0 {
+		return "", fmt.Errorf("could not load mapping on path: %v, could be missing or empty, error: %v", mappingPath, err)
+	}
+
+	synonyms := make([]string, 0)
+	if synonymsPath != "" {
+		file, err := os.Open(synonymsPath)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return "", fmt.Errorf("could not load synonyms on path: %v, error: %v", synonymsPath, err)
+		default:
+			defer file.Close()
+			if synonyms, err = parseSynonyms(file); err != nil {
+				return "", fmt.Errorf("could not parse synonyms on path: %v, error: %v", synonymsPath, err)
+			}
+		}
+	}
+	return withSynonyms(string(mapping), synonyms)
+}
+
+// parseSynonyms reads synonyms rules on solr format, one per line, eg. 'dev, desenvolvedor' or 'ti => tecnologia da informacao'.
+// rules are lowercased and without accents as the analyzers, empty lines and lines starting with '#' are ignored
+func parseSynonyms(reader io.Reader) ([]string, error) {
+	rules := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		rule := strings.TrimSpace(scanner.Text())
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		if strings.Count(rule, "=>") > 1 {
+			return nil, fmt.Errorf("invalid rule on line %d: %s", line, rule)
+		}
+		for _, side := range strings.Split(rule, "=>") {
+			for _, term := range strings.Split(side, ",") {
+				if strings.TrimSpace(term) == "" {
+					return nil, fmt.Errorf("empty term on line %d: %s", line, rule)
+				}
+			}
+		}
+		rules = append(rules, strings.ToLower(removeAccents(rule)))
+	}
+	return rules, scanner.Err()
+}
+
+// withSynonyms sets synonyms rules on mapping synonym filter, mapping is returned unchanged when it has no synonym filter.
+// without rules the filter is removed from filters and analyzers, elasticsearch rejects synonym filters without synonyms
+func withSynonyms(mapping string, synonyms []string) (string, error) {
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(mapping), &content); err != nil {
+		return "", fmt.Errorf("invalid mapping, error: %v", err)
+	}
+	settings, _ := content["settings"].(map[string]interface{})
+	analysis, _ := settings["analysis"].(map[string]interface{})
+	filters, _ := analysis["filter"].(map[string]interface{})
+	filter, ok := filters[synonymsFilter].(map[string]interface{})
+	if !ok {
+		return mapping, nil
+	}
+
+	if len(synonyms) == 0 {
+		delete(filters, synonymsFilter)
+		analyzers, _ := analysis["analyzer"].(map[string]interface{})
+		for _, value := range analyzers {
+			analyzer, _ := value.(map[string]interface{})
+			chain, ok := analyzer["filter"].([]interface{})
+			if !ok {
+				continue
+			}
+			kept := make([]interface{}, 0, len(chain))
+			for _, name := range chain {
+				if name != synonymsFilter {
+					kept = append(kept, name)
+				}
+			}
+			analyzer["filter"] = kept
+		}
+	} else {
+		filter["synonyms"] = synonyms
+	}
+	result, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("invalid mapping, error: %v", err)
+	}
+	return string(result), nil
+}
+
+// withPercolator adds the percolator type to jobs mapping, queries stored on an index with the jobs fields are parsed as on jobs index
+func withPercolator(mapping string) (string, error) {
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(mapping), &content); err != nil {
+		return "", fmt.Errorf("invalid mapping, error: %v", err)
+	}
+	mappings, ok := content["mappings"].(map[string]interface{})
+	if !ok {
+		mappings = make(map[string]interface{})
+		content["mappings"] = mappings
+	}
+	mappings[percolatorType] = map[string]interface{}{
+		"properties": map[string]interface{}{
+			percolatorField: map[string]interface{}{"type": "percolator"},
+		},
+	}
+
+	result, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("invalid mapping, error: %v", err)
+	}
+	return string(result), nil
+}
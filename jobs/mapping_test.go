@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseSynonyms(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", []string{}, false},
+		{"rules", "# comment\n\nDev, Desenvolvedor\nTI => Tecnologia da Informação\n", []string{"dev, desenvolvedor", "ti => tecnologia da informacao"}, false},
+		{"empty term", "dev,,programador", nil, true},
+		{"multiple mappings", "a => b => c", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSynonyms(strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSynonyms() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSynonyms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withSynonyms(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  string
+		synonyms []string
+		want     string
+		wantErr  bool
+	}{
+		{"invalid mapping", "{", nil, "", true},
+		{"no synonym filter", `{"mappings":{}}`, []string{"a, b"}, `{"mappings":{}}`, false},
+		{"synonym filter", `{"settings":{"analysis":{"filter":{"job_synonyms":{"type":"synonym","synonyms":[]}}}}}`, []string{"a, b"}, `{"settings":{"analysis":{"filter":{"job_synonyms":{"synonyms":["a, b"],"type":"synonym"}}}}}`, false},
+		{"without synonyms", `{"settings":{"analysis":{"filter":{"job_synonyms":{"type":"synonym","synonyms":[]},"stop":{"type":"stop"}},"analyzer":{"a":{"filter":["lowercase","job_synonyms","stop"]},"b":{"tokenizer":"standard"}}}}}`, []string{}, `{"settings":{"analysis":{"analyzer":{"a":{"filter":["lowercase","stop"]},"b":{"tokenizer":"standard"}},"filter":{"stop":{"type":"stop"}}}}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withSynonyms(tt.mapping, tt.synonyms)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("withSynonyms() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("withSynonyms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withPercolator(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+		want    string
+		wantErr bool
+	}{
+		{"invalid mapping", "{", "", true},
+		{"no mappings", `{"settings":{}}`, `{"mappings":{"percolator":{"properties":{"query":{"type":"percolator"}}}},"settings":{}}`, false},
+		{"job mapping", `{"mappings":{"job":{"properties":{"title":{"type":"text"}}}}}`, `{"mappings":{"job":{"properties":{"title":{"type":"text"}}},"percolator":{"properties":{"query":{"type":"percolator"}}}}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withPercolator(tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("withPercolator() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("withPercolator() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadMapping(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(dir+"/synonyms.txt", []byte("dev, programador"), 0644)
+	ioutil.WriteFile(dir+"/invalid.txt", []byte("dev,,programador"), 0644)
+
+	tests := []struct {
+		name         string
+		mappingPath  string
+		synonymsPath string
+		wantSynonyms string
+		wantErr      bool
+	}{
+		{"missing mapping", dir + "/missing.json", "", "", true},
+		{"missing synonyms", "../cfg/jobs-mapping.json", dir + "/missing.txt", `"filter":["lowercase","asciifolding","brazilian_stop","brazilian_stemmer"]`, false},
+		{"invalid synonyms", "../cfg/jobs-mapping.json", dir + "/invalid.txt", "", true},
+		{"synonyms", "../cfg/jobs-mapping.json", dir + "/synonyms.txt", `"synonyms":["dev, programador"]`, false},
+		{"default synonyms", "../cfg/jobs-mapping.json", "../cfg/jobs-synonyms.txt", `"ti, tecnologia da informacao"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadMapping(tt.mappingPath, tt.synonymsPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadMapping() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !strings.Contains(got, tt.wantSynonyms) {
+				t.Errorf("loadMapping() = %v, want synonyms %v", got, tt.wantSynonyms)
+			}
+		})
+	}
+}
@@ -0,0 +1,1075 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// InMemoryRepository Repository impl in memory for local development and tests, string fields are indexed on an inverted index of
+// lowercase tokens without accents, there is no stemming, stop words or synonyms and mappings are ignored
+type InMemoryRepository struct {
+	indexes map[string]*memoryIndex
+	rmutex  sync.RWMutex
+}
+
+// newInMemoryRepository InMemoryRepository constructor
+func newInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{indexes: make(map[string]*memoryIndex)}
+}
+
+// memoryIndex documents by id and positions of each token by field and document, string fields are indexed by their dotted path
+type memoryIndex struct {
+	docs        map[string]*memoryDocument
+	postings    map[string]map[string]map[string][]int
+	percolators map[string][]Query
+}
+
+type memoryDocument struct {
+	id     string
+	typ    string
+	source json.RawMessage
+	fields map[string]interface{}
+	tokens map[string][]string
+}
+
+// positionGap positions between values of array fields, phrases never match across values
+const positionGap = 100
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{docs: make(map[string]*memoryDocument), postings: make(map[string]map[string]map[string][]int), percolators: make(map[string][]Query)}
+}
+
+func (r *InMemoryRepository) index(name string) *memoryIndex {
+	index, ok := r.indexes[name]
+	if !ok {
+		index = newMemoryIndex()
+		r.indexes[name] = index
+	}
+	return index
+}
+
+// InitIndex creates the index when missing, mapping is ignored
+func (r *InMemoryRepository) InitIndex(ctx context.Context, name, mapping string) error {
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	r.index(name)
+	return nil
+}
+
+// Add add content do index
+func (r *InMemoryRepository) Add(ctx context.Context, index string, content Indexable) error {
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	if _, err := r.index(index).add(content); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BulkAdd add all contents to index, returns the result of each item
+func (r *InMemoryRepository) BulkAdd(ctx context.Context, index string, items []Indexable) ([]BulkItemResult, error) {
+	if len(items) < 1 {
+		return nil, NewInvalidRequestError("items is empty")
+	}
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+
+	result := make([]BulkItemResult, len(items))
+	for i, item := range items {
+		created, err := r.index(index).add(item)
+		switch {
+		case err != nil:
+			result[i] = BulkItemResult{ID: item.ID(), Result: ItemFailed, Error: err}
+		case created:
+			result[i] = BulkItemResult{ID: item.ID(), Result: ItemCreated}
+		default:
+			result[i] = BulkItemResult{ID: item.ID(), Result: ItemUpdated}
+		}
+	}
+	return result, nil
+}
+
+// Delete remove content from index by id, percolator type removes stored queries
+func (r *InMemoryRepository) Delete(ctx context.Context, index, typ, id string) error {
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	if err := r.index(index).delete(typ, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BulkDelete remove contents from index by id, returns the result of each id
+func (r *InMemoryRepository) BulkDelete(ctx context.Context, index, typ string, ids []string) ([]BulkItemResult, error) {
+	if len(ids) < 1 {
+		return nil, NewInvalidRequestError("ids is empty")
+	}
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+
+	result := make([]BulkItemResult, len(ids))
+	for i, id := range ids {
+		if err := r.index(index).delete(typ, id); err != nil {
+			result[i] = BulkItemResult{ID: id, Result: ItemFailed, Error: err}
+			continue
+		}
+		result[i] = BulkItemResult{ID: id, Result: ItemDeleted}
+	}
+	return result, nil
+}
+
+// Get get content from index by id
+func (r *InMemoryRepository) Get(ctx context.Context, index, id string) (json.RawMessage, error) {
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+	if i, ok := r.indexes[index]; ok {
+		if doc, ok := i.docs[id]; ok {
+			return doc.source, nil
+		}
+	}
+	return nil, NewNotFoundError(fmt.Sprintf("id %s not found", id))
+}
+
+// Count count contents on index matching queries
+func (r *InMemoryRepository) Count(ctx context.Context, index string, queries ...Query) (int64, error) {
+	if len(queries) < 1 {
+		return 0, NewInvalidRequestError("queries is empty")
+	}
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+	i, ok := r.indexes[index]
+	if !ok {
+		return 0, nil
+	}
+	return int64(len(i.match(Query{Bool: &Bool{Must: queries}}))), nil
+}
+
+// DeleteByQuery remove all contents on index matching queries
+func (r *InMemoryRepository) DeleteByQuery(ctx context.Context, index string, queries ...Query) (*DeleteByQueryResult, error) {
+	if len(queries) < 1 {
+		return nil, NewInvalidRequestError("queries is empty")
+	}
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	i, ok := r.indexes[index]
+	if !ok {
+		return &DeleteByQueryResult{}, nil
+	}
+	matches := i.match(Query{Bool: &Bool{Must: queries}})
+	for id := range matches {
+		i.remove(id)
+	}
+	return &DeleteByQueryResult{Matched: int64(len(matches)), Deleted: int64(len(matches))}, nil
+}
+
+// Search search content on index, hits are sorted by sorts with type and id as tie-breaker
+func (r *InMemoryRepository) Search(ctx context.Context, index string, sorts []Sort, page *Page, facets []Facet, highlight *Highlight, queries ...Query) (*SearchResult, error) {
+	if len(queries) < 1 {
+		return nil, NewInvalidRequestError("queries is empty")
+	}
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+	i, ok := r.indexes[index]
+	if !ok {
+		i = newMemoryIndex()
+	}
+
+	matches := i.match(Query{Bool: &Bool{Must: queries}})
+	hits := make([]memoryHit, 0, len(matches))
+	for id, score := range matches {
+		doc := i.docs[id]
+		hits = append(hits, memoryHit{doc: doc, score: score, sort: sortValues(doc, score, sorts)})
+	}
+	sort.Slice(hits, func(a, b int) bool { return compareSortValues(hits[a].sort, hits[b].sort, sorts) < 0 })
+
+	if page != nil {
+		hits = paginate(hits, page, sorts)
+	}
+	result := &SearchResult{Total: int64(len(matches)), Facets: i.facets(matches, facets)}
+	for _, hit := range hits {
+		score := hit.score
+		searchHit := SearchHit{ID: hit.doc.id, Score: &score, Source: hit.doc.source}
+		if highlight != nil {
+			searchHit.Highlight = highlightDocument(hit.doc, highlight, queries)
+		}
+		result.Hits = append(result.Hits, searchHit)
+		if len(sorts) > 0 {
+			result.LastSort = hit.sort
+		}
+	}
+	return result, nil
+}
+
+// Suggest completes prefix with the distinct values of field starting with prefix, sub fields as 'title.suggest' use the parent value
+func (r *InMemoryRepository) Suggest(ctx context.Context, index, field, prefix string, size int) ([]Suggestion, error) {
+	if prefix == "" {
+		return nil, NewInvalidRequestError("prefix is empty")
+	}
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+
+	suggestions := make([]Suggestion, 0)
+	i, ok := r.indexes[index]
+	if !ok {
+		return suggestions, nil
+	}
+	folded := foldText(prefix)
+	found := make(map[string]bool)
+	for _, doc := range i.docs {
+		for _, value := range fieldValues(doc.fields, field) {
+			text, ok := value.(string)
+			if ok && !found[text] && strings.HasPrefix(foldText(text), folded) {
+				found[text] = true
+				suggestions = append(suggestions, Suggestion{Text: text, Score: 1})
+			}
+		}
+	}
+	sort.Slice(suggestions, func(a, b int) bool { return suggestions[a].Text < suggestions[b].Text })
+	if len(suggestions) > size {
+		suggestions = suggestions[:size]
+	}
+	return suggestions, nil
+}
+
+// AddPercolator stores queries with id on index, documents matching the queries are found by Percolate
+func (r *InMemoryRepository) AddPercolator(ctx context.Context, index, id string, queries ...Query) error {
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	r.index(index).percolators[id] = queries
+	return nil
+}
+
+// Percolate finds the ids of the stored queries matching each document, on the same order the documents were sent
+func (r *InMemoryRepository) Percolate(ctx context.Context, index string, docs []Indexable) ([][]string, error) {
+	if len(docs) < 1 {
+		return nil, NewInvalidRequestError("docs is empty")
+	}
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+
+	result := make([][]string, len(docs))
+	i, ok := r.indexes[index]
+	if !ok {
+		return result, nil
+	}
+	ids := make([]string, 0, len(i.percolators))
+	for id := range i.percolators {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for d, doc := range docs {
+		single := newMemoryIndex()
+		if _, err := single.add(doc); err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if len(single.match(Query{Bool: &Bool{Must: i.percolators[id]}})) > 0 && len(result[d]) < maxPercolateMatches {
+				result[d] = append(result[d], id)
+			}
+		}
+	}
+	return result, nil
+}
+
+// add indexes content replacing the previous version, returns true when the content is new
+func (i *memoryIndex) add(content Indexable) (bool, *JobError) {
+	source, err := json.Marshal(content)
+	if err != nil {
+		return false, NewParserError(fmt.Sprintf("error indexing content, message: %s", err.Error()))
+	}
+	return i.addSource(content.ID(), indexType(content), source)
+}
+
+// addSource indexes the json source of a document of type typ replacing the previous version, returns true when the document is new
+func (i *memoryIndex) addSource(id, typ string, source json.RawMessage) (bool, *JobError) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(source, &fields); err != nil {
+		return false, NewParserError(fmt.Sprintf("error indexing content, message: %s", err.Error()))
+	}
+
+	_, exists := i.docs[id]
+	if exists {
+		i.remove(id)
+	}
+	doc := &memoryDocument{id: id, typ: typ, source: source, fields: fields, tokens: make(map[string][]string)}
+	positions := make(map[string]int)
+	walkStrings(fields, "", func(field, value string) {
+		for _, token := range analyze(value) {
+			if i.postings[field] == nil {
+				i.postings[field] = make(map[string]map[string][]int)
+			}
+			if i.postings[field][token] == nil {
+				i.postings[field][token] = make(map[string][]int)
+			}
+			i.postings[field][token][doc.id] = append(i.postings[field][token][doc.id], positions[field])
+			doc.tokens[field] = append(doc.tokens[field], token)
+			positions[field]++
+		}
+		positions[field] += positionGap
+	})
+	i.docs[doc.id] = doc
+	return !exists, nil
+}
+
+func (i *memoryIndex) delete(typ, id string) *JobError {
+	if typ == percolatorType {
+		if _, ok := i.percolators[id]; !ok {
+			return NewNotFoundError(fmt.Sprintf("id %s not found", id))
+		}
+		delete(i.percolators, id)
+		return nil
+	}
+	if doc, ok := i.docs[id]; !ok || doc.typ != typ {
+		return NewNotFoundError(fmt.Sprintf("id %s not found", id))
+	}
+	i.remove(id)
+	return nil
+}
+
+func (i *memoryIndex) remove(id string) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for field, tokens := range doc.tokens {
+		for _, token := range tokens {
+			delete(i.postings[field][token], id)
+			if len(i.postings[field][token]) == 0 {
+				delete(i.postings[field], token)
+			}
+		}
+	}
+	delete(i.docs, id)
+}
+
+// walkStrings calls fn with the dotted path of every string on value, arrays are flattened
+func walkStrings(value interface{}, path string, fn func(field, value string)) {
+	switch v := value.(type) {
+	case string:
+		fn(path, v)
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, path, fn)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			walkStrings(item, key, fn)
+		}
+	}
+}
+
+// fieldValues values of a dotted path field, arrays are flattened and remaining path of a value is a sub field as 'cidade.keyword'
+func fieldValues(value interface{}, field string) []interface{} {
+	var path []string
+	if field != "" {
+		path = strings.Split(field, ".")
+	}
+	return pathValues(value, path)
+}
+
+func pathValues(value interface{}, path []string) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var values []interface{}
+		for _, item := range v {
+			values = append(values, pathValues(item, path)...)
+		}
+		return values
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return []interface{}{v}
+		}
+		return pathValues(v[path[0]], path[1:])
+	default:
+		return []interface{}{v}
+	}
+}
+
+// analyze splits text on lowercase tokens without accents, html tags are removed
+func analyze(text string) []string {
+	return strings.FieldsFunc(foldText(htmlTag.ReplaceAllString(text, " ")), isTokenSeparator)
+}
+
+func foldText(text string) string {
+	return strings.ToLower(removeAccents(text))
+}
+
+func isTokenSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// match finds the documents matching query with their scores, ranges and terms are filters and do not score
+func (i *memoryIndex) match(q Query) map[string]float64 {
+	switch {
+	case q.Bool != nil:
+		return i.matchBool(*q.Bool)
+	case q.Range != nil:
+		return i.filter(func(doc *memoryDocument) bool { return matchRange(doc, *q.Range) })
+	case q.Term != nil:
+		return i.filter(func(doc *memoryDocument) bool { return matchTerm(doc, *q.Term) })
+	case q.Like != nil:
+		return i.matchLike(q)
+	case q.Match != "":
+		return i.matchPlain(q)
+	}
+	return i.matchText(q)
+}
+
+func (i *memoryIndex) filter(fn func(doc *memoryDocument) bool) map[string]float64 {
+	result := make(map[string]float64)
+	for id, doc := range i.docs {
+		if fn(doc) {
+			result[id] = 0
+		}
+	}
+	return result
+}
+
+// matchBool an empty bool matches every document as match all
+func (i *memoryIndex) matchBool(b Bool) map[string]float64 {
+	var result map[string]float64
+	if len(b.Must) == 0 && len(b.Should) == 0 {
+		result = i.filter(func(doc *memoryDocument) bool { return true })
+		for id := range result {
+			result[id] = 1
+		}
+	}
+	for _, q := range b.Must {
+		result = intersect(result, i.match(q))
+	}
+	if len(b.Should) > 0 {
+		should := make(map[string]float64)
+		for _, q := range b.Should {
+			should = union(should, i.match(q))
+		}
+		result = intersect(result, should)
+	}
+	for _, q := range b.MustNot {
+		for id := range i.match(q) {
+			delete(result, id)
+		}
+	}
+	return result
+}
+
+// intersect sums the scores of documents on both, a nil result has every document
+func intersect(result, matches map[string]float64) map[string]float64 {
+	if result == nil {
+		return matches
+	}
+	for id, score := range result {
+		if s, ok := matches[id]; ok {
+			result[id] = score + s
+		} else {
+			delete(result, id)
+		}
+	}
+	return result
+}
+
+func union(result, matches map[string]float64) map[string]float64 {
+	for id, score := range matches {
+		result[id] += score
+	}
+	return result
+}
+
+func matchRange(doc *memoryDocument, r Range) bool {
+	for _, value := range fieldValues(doc.fields, r.Field) {
+		f, ok := value.(float64)
+		if !ok {
+			continue
+		}
+		if r.Min != nil && (f < *r.Min || r.ExcludeMin && f == *r.Min) {
+			continue
+		}
+		if r.Max != nil && (f > *r.Max || r.ExcludeMax && f == *r.Max) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func matchTerm(doc *memoryDocument, t Term) bool {
+	for _, value := range fieldValues(doc.fields, t.Field) {
+		if fmt.Sprint(value) == t.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchText simple query string semantics, '|' splits alternative groups and any group must match.
+// on each group every term must match with 'and' operator or '+' and any with 'or',
+// quoted phrases match consecutive tokens, words with trailing '*' are prefixes and words with leading '-' are excluded
+func (i *memoryIndex) matchText(q Query) map[string]float64 {
+	result := make(map[string]float64)
+	var terms []string
+	for _, term := range append(queryTerms.FindAllString(q.Value, -1), "|") {
+		if term != "|" {
+			terms = append(terms, term)
+			continue
+		}
+		if len(terms) > 0 {
+			result = union(result, i.matchGroup(q, terms))
+		}
+		terms = nil
+	}
+	return result
+}
+
+// matchGroup words between '|', like createElasticFuzzyGroup
+func (i *memoryIndex) matchGroup(q Query, terms []string) map[string]float64 {
+	required := q.Operator == "and"
+	var included, excluded []map[string]float64
+	for _, term := range terms {
+		switch {
+		case term == "+":
+			required = true
+		case len(term) > 1 && strings.HasPrefix(term, "-"):
+			excluded = append(excluded, i.matchTerm(term[1:], q.Fields, q.Fuzziness))
+		default:
+			included = append(included, i.matchTerm(term, q.Fields, q.Fuzziness))
+		}
+	}
+
+	var result map[string]float64
+	for _, matches := range included {
+		if required {
+			result = intersect(result, matches)
+		} else {
+			if result == nil {
+				result = make(map[string]float64)
+			}
+			result = union(result, matches)
+		}
+	}
+	if result == nil {
+		result = make(map[string]float64)
+	}
+	for _, matches := range excluded {
+		for id := range matches {
+			delete(result, id)
+		}
+	}
+	return result
+}
+
+// matchPlain matches plain text without operators, every word or the words as a phrase
+func (i *memoryIndex) matchPlain(q Query) map[string]float64 {
+	tokens := analyze(q.Value)
+	return i.matchFields(q.Fields, func(field string) map[string]float64 {
+		if q.Match == MatchPhrase {
+			return i.matchPhrase(field, tokens)
+		}
+		return i.matchTokens(field, tokens, q.Fuzziness, false)
+	})
+}
+
+// matchTerm matches a single term on the best of fields
+func (i *memoryIndex) matchTerm(term string, fields []string, fuzziness string) map[string]float64 {
+	return i.matchFields(fields, func(field string) map[string]float64 {
+		switch {
+		case strings.HasPrefix(term, `"`):
+			return i.matchPhrase(field, analyze(strings.Trim(term, `"`)))
+		case strings.HasSuffix(term, "*"):
+			return i.matchTokens(field, analyze(strings.TrimSuffix(term, "*")), "", true)
+		}
+		return i.matchTokens(field, analyze(term), fuzziness, false)
+	})
+}
+
+// matchFields keeps the best score of each document on fields, fields may have a boost as 'title^3'
+func (i *memoryIndex) matchFields(fields []string, match func(field string) map[string]float64) map[string]float64 {
+	result := make(map[string]float64)
+	for _, f := range fields {
+		field, boost := parseBoost(f)
+		for id, score := range match(field) {
+			result[id] = math.Max(result[id], score*boost)
+		}
+	}
+	return result
+}
+
+func parseBoost(field string) (string, float64) {
+	parts := strings.SplitN(field, "^", 2)
+	if len(parts) == 2 {
+		if boost, err := strconv.ParseFloat(parts[1], 64); err == nil {
+			return parts[0], boost
+		}
+	}
+	return field, 1
+}
+
+// matchTokens every token must match, the last one as prefix when prefix is set
+func (i *memoryIndex) matchTokens(field string, tokens []string, fuzziness string, prefix bool) map[string]float64 {
+	if len(tokens) == 0 {
+		return make(map[string]float64)
+	}
+	var result map[string]float64
+	for t, token := range tokens {
+		matches := make(map[string]float64)
+		isPrefix := prefix && t == len(tokens)-1
+		if !isPrefix && fuzziness == "" {
+			i.scorePostings(matches, i.postings[field][token])
+		} else {
+			//only prefix and fuzzy tokens scan the field vocabulary
+			for candidate, postings := range i.postings[field] {
+				if matchToken(token, candidate, fuzziness, isPrefix) {
+					i.scorePostings(matches, postings)
+				}
+			}
+		}
+		result = intersect(result, matches)
+	}
+	return result
+}
+
+// scorePostings adds to matches the score of each document on postings of a token, keeping the best score of documents
+func (i *memoryIndex) scorePostings(matches map[string]float64, postings map[string][]int) {
+	if len(postings) == 0 {
+		return
+	}
+	idf := i.idf(len(postings))
+	for id, positions := range postings {
+		matches[id] = math.Max(matches[id], (1+math.Log(float64(len(positions))))*idf)
+	}
+}
+
+func matchToken(token, candidate, fuzziness string, prefix bool) bool {
+	switch {
+	case token == candidate:
+		return true
+	case prefix:
+		return strings.HasPrefix(candidate, token)
+	case fuzziness != "":
+		return levenshtein(token, candidate) <= maxEdits(token, fuzziness)
+	}
+	return false
+}
+
+// maxEdits edit distance allowed for fuzziness, 'AUTO' allows no edit up to 2 characters, 1 up to 5 and 2 for longer tokens
+func maxEdits(token, fuzziness string) int {
+	if edits, err := strconv.Atoi(fuzziness); err == nil {
+		return edits
+	}
+	switch length := len([]rune(token)); {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous = current
+	}
+	return previous[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// matchPhrase tokens must be on consecutive positions
+func (i *memoryIndex) matchPhrase(field string, tokens []string) map[string]float64 {
+	result := make(map[string]float64)
+	if len(tokens) == 0 {
+		return result
+	}
+	for id, positions := range i.postings[field][tokens[0]] {
+		for _, position := range positions {
+			if i.hasPhrase(field, id, tokens[1:], position+1) {
+				result[id] += i.idf(len(i.postings[field][tokens[0]])) * float64(len(tokens))
+			}
+		}
+	}
+	return result
+}
+
+func (i *memoryIndex) hasPhrase(field, id string, tokens []string, position int) bool {
+	for t, token := range tokens {
+		found := false
+		for _, p := range i.postings[field][token][id] {
+			if p == position+t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// idf inverse document frequency of a token found on count documents
+func (i *memoryIndex) idf(count int) float64 {
+	return math.Log(1 + (float64(len(i.docs))-float64(count)+0.5)/(float64(count)+0.5))
+}
+
+// matchLike more like this, documents sharing at least 30% of the distinct tokens of the liked documents on fields, liked documents are not matched
+func (i *memoryIndex) matchLike(q Query) map[string]float64 {
+	liked := make(map[string]bool)
+	terms := make(map[string]map[string]bool)
+	for _, id := range q.Like.IDs {
+		doc, ok := i.docs[id]
+		if !ok || doc.typ != q.Like.Type {
+			continue
+		}
+		liked[id] = true
+		for _, f := range q.Fields {
+			field, _ := parseBoost(f)
+			for _, token := range doc.tokens[field] {
+				if terms[field] == nil {
+					terms[field] = make(map[string]bool)
+				}
+				terms[field][token] = true
+			}
+		}
+	}
+
+	total := 0
+	for _, tokens := range terms {
+		total += len(tokens)
+	}
+	shared := make(map[string]int)
+	scores := make(map[string]float64)
+	for field, tokens := range terms {
+		for token := range tokens {
+			postings := i.postings[field][token]
+			for id := range postings {
+				if !liked[id] {
+					shared[id]++
+					scores[id] += i.idf(len(postings))
+				}
+			}
+		}
+	}
+
+	result := make(map[string]float64)
+	minShared := int(math.Ceil(float64(total) * 0.3))
+	for id, count := range shared {
+		if count >= minShared {
+			result[id] = scores[id]
+		}
+	}
+	return result
+}
+
+type memoryHit struct {
+	doc   *memoryDocument
+	score float64
+	sort  []interface{}
+}
+
+// sortValues values of sorts on document followed by '_uid' tie-breaker, multi valued fields use the min value ascending and the max descending
+func sortValues(doc *memoryDocument, score float64, sorts []Sort) []interface{} {
+	if len(sorts) == 0 {
+		sorts = []Sort{{Field: ScoreField}}
+	}
+	values := make([]interface{}, 0, len(sorts)+1)
+	for _, s := range sorts {
+		if s.Field == ScoreField {
+			values = append(values, score)
+			continue
+		}
+		var value interface{}
+		for _, v := range fieldValues(doc.fields, s.Field) {
+			if value == nil || (compareValues(v, value) < 0) == s.Ascending {
+				value = v
+			}
+		}
+		values = append(values, value)
+	}
+	return append(values, doc.typ+"#"+doc.id)
+}
+
+// compareSortValues compares values with the order of sorts, missing values are always last and '_uid' is ascending
+func compareSortValues(a, b []interface{}, sorts []Sort) int {
+	if len(sorts) == 0 {
+		sorts = []Sort{{Field: ScoreField}}
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ascending := i >= len(sorts) || sorts[i].Ascending
+		switch {
+		case a[i] == nil && b[i] == nil:
+			continue
+		case a[i] == nil:
+			return 1
+		case b[i] == nil:
+			return -1
+		}
+		if c := compareValues(a[i], b[i]); c != 0 {
+			if ascending {
+				return c
+			}
+			return -c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	fa, aNumber := a.(float64)
+	fb, bNumber := b.(float64)
+	if aNumber && bNumber {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// paginate keeps hits after page search after values or from page offset, limited to page size
+func paginate(hits []memoryHit, page *Page, sorts []Sort) []memoryHit {
+	from := page.From
+	if len(page.SearchAfter) > 0 {
+		from = sort.Search(len(hits), func(i int) bool { return compareSortValues(hits[i].sort, page.SearchAfter, sorts) > 0 })
+	}
+	if from > len(hits) {
+		from = len(hits)
+	}
+	to := from + page.Size
+	if to > len(hits) {
+		to = len(hits)
+	}
+	return hits[from:to]
+}
+
+// facets terms are sorted by count and key, ranges have the same keys as elasticsearch range aggregation
+func (i *memoryIndex) facets(matches map[string]float64, facets []Facet) map[string][]FacetBucket {
+	if len(facets) == 0 {
+		return nil
+	}
+	result := make(map[string][]FacetBucket)
+	for _, f := range facets {
+		if len(f.Edges) == 0 {
+			result[f.Name] = i.termsFacet(matches, f)
+		} else {
+			result[f.Name] = i.rangeFacet(matches, f)
+		}
+	}
+	return result
+}
+
+func (i *memoryIndex) termsFacet(matches map[string]float64, f Facet) []FacetBucket {
+	counts := make(map[string]int64)
+	sums := make(map[string]float64)
+	for id := range matches {
+		objects := []interface{}{i.docs[id].fields}
+		field, sum := f.Field, f.Sum
+		if f.Path != "" {
+			objects = fieldValues(i.docs[id].fields, f.Path)
+			field, sum = strings.TrimPrefix(field, f.Path+"."), strings.TrimPrefix(sum, f.Path+".")
+		}
+		found := make(map[string]bool)
+		for _, object := range objects {
+			for _, value := range fieldValues(object, field) {
+				key := fmt.Sprint(value)
+				if !found[key] {
+					found[key] = true
+					counts[key]++
+				}
+				if f.Sum != "" {
+					for _, s := range fieldValues(object, sum) {
+						if n, ok := s.(float64); ok {
+							sums[key] += n
+						}
+					}
+				}
+			}
+		}
+	}
+
+	buckets := make([]FacetBucket, 0, len(counts))
+	for key, count := range counts {
+		bucket := FacetBucket{Key: key, Count: count}
+		if f.Sum != "" {
+			sum := sums[key]
+			bucket.Sum = &sum
+		}
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(a, b int) bool {
+		if buckets[a].Count == buckets[b].Count {
+			return buckets[a].Key < buckets[b].Key
+		}
+		return buckets[a].Count > buckets[b].Count
+	})
+	if f.Size > 0 && len(buckets) > f.Size {
+		buckets = buckets[:f.Size]
+	}
+	return buckets
+}
+
+func (i *memoryIndex) rangeFacet(matches map[string]float64, f Facet) []FacetBucket {
+	edges := make([]*float64, len(f.Edges))
+	for e := range f.Edges {
+		edge := f.Edges[e]
+		edges[e] = &edge
+	}
+	buckets := []FacetBucket{{Key: "*-" + formatEdge(f.Edges[0]), To: edges[0]}}
+	for e := 1; e < len(edges); e++ {
+		buckets = append(buckets, FacetBucket{Key: formatEdge(f.Edges[e-1]) + "-" + formatEdge(f.Edges[e]), From: edges[e-1], To: edges[e]})
+	}
+	buckets = append(buckets, FacetBucket{Key: formatEdge(f.Edges[len(f.Edges)-1]) + "-*", From: edges[len(edges)-1]})
+
+	for id := range matches {
+		for b := range buckets {
+			if matchRange(i.docs[id], Range{Field: f.Field, Min: buckets[b].From, Max: buckets[b].To, ExcludeMax: true}) {
+				buckets[b].Count++
+			}
+		}
+	}
+	return buckets
+}
+
+// formatEdge formats as elasticsearch range keys, as '1000.0'
+func formatEdge(edge float64) string {
+	s := strconv.FormatFloat(edge, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// highlightDocument wraps the words of fields matching text query tokens, values without matches are not returned
+func highlightDocument(doc *memoryDocument, h *Highlight, queries []Query) map[string][]string {
+	tokens := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	for _, q := range queries {
+		highlightTokens(q, tokens, prefixes)
+	}
+
+	result := make(map[string][]string)
+	for _, field := range h.Fields {
+		for _, value := range fieldValues(doc.fields, field) {
+			text, ok := value.(string)
+			if !ok || (h.Fragments > 0 && len(result[field]) >= h.Fragments) {
+				continue
+			}
+			if fragment, ok := highlightText(text, h, tokens, prefixes); ok {
+				result[field] = append(result[field], fragment)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func highlightTokens(q Query, tokens, prefixes map[string]bool) {
+	if q.Bool != nil {
+		for _, clause := range append(q.Bool.Must, q.Bool.Should...) {
+			highlightTokens(clause, tokens, prefixes)
+		}
+		return
+	}
+	if q.Range != nil || q.Term != nil || q.Like != nil {
+		return
+	}
+	if q.Match != "" {
+		for _, token := range analyze(q.Value) {
+			tokens[token] = true
+		}
+		return
+	}
+	for _, term := range queryTerms.FindAllString(q.Value, -1) {
+		if strings.HasPrefix(term, "-") {
+			continue
+		}
+		for _, token := range analyze(strings.TrimSuffix(term, "*")) {
+			if strings.HasSuffix(term, "*") {
+				prefixes[token] = true
+			} else {
+				tokens[token] = true
+			}
+		}
+	}
+}
+
+var highlightWords = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// highlightText wraps matching words, the fragment starts before the first match and has at most fragment size characters before wrapping
+func highlightText(text string, h *Highlight, tokens, prefixes map[string]bool) (string, bool) {
+	matches := func(word string) bool {
+		folded := foldText(word)
+		if tokens[folded] {
+			return true
+		}
+		for prefix := range prefixes {
+			if strings.HasPrefix(folded, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := -1
+	for _, loc := range highlightWords.FindAllStringIndex(text, -1) {
+		if matches(text[loc[0]:loc[1]]) {
+			first = loc[0]
+			break
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+	if runes := []rune(text); h.FragmentSize > 0 && len(runes) > h.FragmentSize {
+		start := len([]rune(text[:first])) - h.FragmentSize/4
+		if start < 0 {
+			start = 0
+		}
+		if start+h.FragmentSize > len(runes) {
+			start = len(runes) - h.FragmentSize
+		}
+		text = string(runes[start : start+h.FragmentSize])
+	}
+	return highlightWords.ReplaceAllStringFunc(text, func(word string) string {
+		if !matches(word) {
+			return word
+		}
+		return h.PreTag + word + h.PostTag
+	}), true
+}
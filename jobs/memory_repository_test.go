@@ -0,0 +1,244 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var memoryJobs = []Job{
+	{Title: "Analista de Sistemas Java", Description: "<p>Desenvolvimento em <b>Java</b> e Spring</p>", Salary: 5000, City: []string{"Joinville"}, Locations: []Location{{City: "Joinville", State: "SC", Openings: 2}}},
+	{Title: "Desenvolvedor Golang", Description: "Microsserviços em Go e Java, desenvolvimento ágil", Salary: 7000, City: []string{"São Paulo"}, Locations: []Location{{City: "São Paulo", State: "SP", Openings: 1}}},
+	{Title: "Estágio em Análise de Sistemas", Description: "Suporte ao time de sistemas", Salary: 1500, City: []string{"Blumenau", "Joinville"}, Locations: []Location{{City: "Blumenau", State: "SC", Openings: 1}, {City: "Joinville", State: "SC", Openings: 1}}},
+	{Title: "Vendedor", Description: "Vendas no varejo", City: []string{"Curitiba"}, Locations: []Location{{City: "Curitiba", State: "PR", Openings: 3}}},
+}
+
+func newMemoryJobsRepository(t *testing.T) *InMemoryRepository {
+	r := newInMemoryRepository()
+	items := make([]Indexable, len(memoryJobs))
+	for i, job := range memoryJobs {
+		items[i] = job
+	}
+	if _, err := r.BulkAdd(context.TODO(), "jobs", items); err != nil {
+		t.Fatalf("InMemoryRepository.BulkAdd() error = %v", err)
+	}
+	return r
+}
+
+func memoryTitles(t *testing.T, hits []SearchHit) []string {
+	titles := []string{}
+	for _, hit := range hits {
+		var job Job
+		if err := json.Unmarshal(hit.Source, &job); err != nil {
+			t.Fatalf("invalid source %s, error = %v", hit.Source, err)
+		}
+		titles = append(titles, job.Title)
+	}
+	return titles
+}
+
+func TestInMemoryRepository_Search(t *testing.T) {
+	content := []string{"title^3", "description"}
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"word without accents", Query{Value: "analise", Fields: content, Operator: "and"}, []string{"Estágio em Análise de Sistemas"}},
+		{"and operator", Query{Value: "sistemas java", Fields: content, Operator: "and"}, []string{"Analista de Sistemas Java"}},
+		{"or operator", Query{Value: "golang vendas", Fields: content, Operator: "or"}, []string{"Desenvolvedor Golang", "Vendedor"}},
+		{"phrase", Query{Value: `"analista de sistemas"`, Fields: content, Operator: "and"}, []string{"Analista de Sistemas Java"}},
+		{"phrase not consecutive", Query{Value: `"sistemas analista"`, Fields: content, Operator: "and"}, []string{}},
+		{"prefix", Query{Value: "desenvolv*", Fields: content, Operator: "and"}, []string{"Desenvolvedor Golang", "Analista de Sistemas Java"}},
+		{"excluded word", Query{Value: "sistemas -estagio", Fields: content, Operator: "and"}, []string{"Analista de Sistemas Java"}},
+		{"required operator", Query{Value: "sistemas+java", Fields: content, Operator: "or"}, []string{"Analista de Sistemas Java"}},
+		{"or operator between groups", Query{Value: "golang | vendas", Fields: content, Operator: "and"}, []string{"Desenvolvedor Golang", "Vendedor"}},
+		{"groups with excluded word", Query{Value: "sistemas -estagio|vendas", Fields: content, Operator: "and"}, []string{"Analista de Sistemas Java", "Vendedor"}},
+		{"plain words", Query{Value: "sistemas|java", Fields: content, Operator: "and", Match: MatchWords}, []string{"Analista de Sistemas Java"}},
+		{"plain words are not prefixes", Query{Value: "desenvolv*", Fields: content, Operator: "and", Match: MatchWords}, []string{}},
+		{"plain phrase", Query{Value: "analista de sistemas", Fields: content, Operator: "and", Match: MatchPhrase}, []string{"Analista de Sistemas Java"}},
+		{"html is not indexed", Query{Value: "b", Fields: content, Operator: "and"}, []string{}},
+		{"fuzzy", Query{Value: "golagn", Fields: content, Operator: "and", Fuzziness: "AUTO"}, []string{"Desenvolvedor Golang"}},
+		{"fuzzy auto keeps short words exact", Query{Value: "ga", Fields: content, Operator: "and", Fuzziness: "AUTO"}, []string{}},
+		{"city", Query{Value: "sao paulo", Fields: []string{"cidade"}, Operator: "and"}, []string{"Desenvolvedor Golang"}},
+		{"range", Query{Range: &Range{Field: "salario", Min: floatPtr(5000), ExcludeMin: true}}, []string{"Desenvolvedor Golang"}},
+		{"nested term", Query{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}}, []string{"Analista de Sistemas Java", "Estágio em Análise de Sistemas"}},
+		{"bool", Query{Bool: &Bool{
+			Must:    []Query{{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}}},
+			Should:  []Query{{Value: "java", Fields: content, Operator: "and"}, {Value: "suporte", Fields: content, Operator: "and"}},
+			MustNot: []Query{{Range: &Range{Field: "salario", Max: floatPtr(2000)}}},
+		}}, []string{"Analista de Sistemas Java"}},
+		{"empty bool matches all", Query{Bool: &Bool{}}, []string{"Desenvolvedor Golang", "Analista de Sistemas Java", "Estágio em Análise de Sistemas", "Vendedor"}},
+		{"like", Query{Fields: []string{"title", "description"}, Like: &Like{Type: "job", IDs: []string{memoryJobs[0].ID()}}}, []string{"Desenvolvedor Golang"}},
+	}
+	r := newMemoryJobsRepository(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := r.Search(context.TODO(), "jobs", []Sort{{Field: "salario"}}, nil, nil, nil, tt.query)
+			if err != nil {
+				t.Errorf("InMemoryRepository.Search() error = %v", err)
+				return
+			}
+			if got := memoryTitles(t, result.Hits); !reflect.DeepEqual(got, tt.want) || result.Total != int64(len(tt.want)) {
+				t.Errorf("InMemoryRepository.Search() = %d %v, want %v", result.Total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInMemoryRepository_SearchPages(t *testing.T) {
+	r := newMemoryJobsRepository(t)
+	sorts := []Sort{{Field: "salario", Ascending: true}}
+	all := Query{Bool: &Bool{}}
+
+	first, err := r.Search(context.TODO(), "jobs", sorts, &Page{From: 0, Size: 2}, nil, nil, all)
+	if err != nil {
+		t.Fatalf("InMemoryRepository.Search() error = %v", err)
+	}
+	if got, want := memoryTitles(t, first.Hits), []string{"Estágio em Análise de Sistemas", "Analista de Sistemas Java"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InMemoryRepository.Search() first page = %v, want %v", got, want)
+	}
+	if want := []interface{}{5000.0, "job#" + memoryJobs[0].ID()}; !reflect.DeepEqual(first.LastSort, want) {
+		t.Errorf("InMemoryRepository.Search() last sort = %v, want %v", first.LastSort, want)
+	}
+
+	next, err := r.Search(context.TODO(), "jobs", sorts, &Page{Size: 2, SearchAfter: first.LastSort}, nil, nil, all)
+	if err != nil {
+		t.Fatalf("InMemoryRepository.Search() error = %v", err)
+	}
+	if got, want := memoryTitles(t, next.Hits), []string{"Desenvolvedor Golang", "Vendedor"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InMemoryRepository.Search() search after = %v, want %v (missing values last)", got, want)
+	}
+}
+
+func TestInMemoryRepository_SearchFacets(t *testing.T) {
+	r := newMemoryJobsRepository(t)
+	facets := []Facet{
+		{Name: "city", Field: "cidade.keyword", Size: 2},
+		{Name: "state", Field: "locations.state", Size: 10, Path: "locations", Sum: "locations.openings"},
+		{Name: "salary", Field: "salario", Edges: []float64{2000, 6000}},
+	}
+	result, err := r.Search(context.TODO(), "jobs", nil, &Page{Size: 0}, facets, nil, Query{Bool: &Bool{}})
+	if err != nil {
+		t.Fatalf("InMemoryRepository.Search() error = %v", err)
+	}
+	want := map[string][]FacetBucket{
+		"city":   {{Key: "Joinville", Count: 2}, {Key: "Blumenau", Count: 1}},
+		"state":  {{Key: "SC", Count: 2, Sum: floatPtr(4)}, {Key: "PR", Count: 1, Sum: floatPtr(3)}, {Key: "SP", Count: 1, Sum: floatPtr(1)}},
+		"salary": {{Key: "*-2000.0", To: floatPtr(2000), Count: 1}, {Key: "2000.0-6000.0", From: floatPtr(2000), To: floatPtr(6000), Count: 1}, {Key: "6000.0-*", From: floatPtr(6000), Count: 1}},
+	}
+	if !reflect.DeepEqual(result.Facets, want) {
+		t.Errorf("InMemoryRepository.Search() facets = %+v, want %+v", result.Facets, want)
+	}
+	if len(result.Hits) != 0 || result.Total != 4 {
+		t.Errorf("InMemoryRepository.Search() = %d hits of %d, want 0 of 4", len(result.Hits), result.Total)
+	}
+}
+
+func TestInMemoryRepository_SearchHighlight(t *testing.T) {
+	r := newMemoryJobsRepository(t)
+	highlight := &Highlight{Fields: []string{"title", "description"}, PreTag: "<em>", PostTag: "</em>", FragmentSize: 20, Fragments: 1}
+	result, err := r.Search(context.TODO(), "jobs", nil, nil, nil, highlight, Query{Value: "golang micro*", Fields: []string{"title", "description"}, Operator: "or"})
+	if err != nil {
+		t.Fatalf("InMemoryRepository.Search() error = %v", err)
+	}
+	want := map[string][]string{"title": {"Desenvolvedor <em>Golang</em>"}, "description": {"<em>Microsserviços</em> em Go"}}
+	if len(result.Hits) != 1 || !reflect.DeepEqual(result.Hits[0].Highlight, want) {
+		t.Errorf("InMemoryRepository.Search() highlight = %+v, want %v", result.Hits, want)
+	}
+}
+
+func TestInMemoryRepository_Suggest(t *testing.T) {
+	r := newMemoryJobsRepository(t)
+	tests := []struct {
+		name   string
+		field  string
+		prefix string
+		size   int
+		want   []Suggestion
+	}{
+		{"folded prefix", "cidade.suggest", "sao", 5, []Suggestion{{Text: "São Paulo", Score: 1}}},
+		{"empty prefix", "title.suggest", "", 1, nil},
+		{"sorted", "title.suggest", "an", 5, []Suggestion{{Text: "Analista de Sistemas Java", Score: 1}}},
+		{"no match", "title.suggest", "xyz", 5, []Suggestion{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Suggest(context.TODO(), "jobs", tt.field, tt.prefix, tt.size)
+			if tt.prefix == "" {
+				if err == nil {
+					t.Errorf("InMemoryRepository.Suggest() expected error for empty prefix")
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InMemoryRepository.Suggest() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInMemoryRepository_Delete(t *testing.T) {
+	r := newMemoryJobsRepository(t)
+	id := memoryJobs[1].ID()
+	if err := r.Delete(context.TODO(), "jobs", "job", id); err != nil {
+		t.Fatalf("InMemoryRepository.Delete() error = %v", err)
+	}
+	if _, err := r.Get(context.TODO(), "jobs", id); err == nil || err.(*JobError).Type() != ERROR_NOT_FOUND {
+		t.Errorf("InMemoryRepository.Get() error = %v, want not found", err)
+	}
+	if count, _ := r.Count(context.TODO(), "jobs", Query{Value: "golang", Fields: []string{"title"}}); count != 0 {
+		t.Errorf("InMemoryRepository.Count() = %d, deleted job is still indexed", count)
+	}
+
+	results, err := r.BulkDelete(context.TODO(), "jobs", "job", []string{memoryJobs[0].ID(), id})
+	if err != nil {
+		t.Fatalf("InMemoryRepository.BulkDelete() error = %v", err)
+	}
+	if results[0].Result != ItemDeleted || results[1].Result != ItemFailed || results[1].Error.Type() != ERROR_NOT_FOUND {
+		t.Errorf("InMemoryRepository.BulkDelete() = %+v, want deleted and not found", results)
+	}
+
+	deleted, err := r.DeleteByQuery(context.TODO(), "jobs", Query{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}})
+	if err != nil || deleted.Deleted != 1 {
+		t.Errorf("InMemoryRepository.DeleteByQuery() = %+v, %v, want 1 deleted", deleted, err)
+	}
+}
+
+func TestInMemoryRepository_BulkAdd(t *testing.T) {
+	r := newMemoryJobsRepository(t)
+	updated := memoryJobs[3]
+	updated.Description = "Vendas de seguros"
+	results, err := r.BulkAdd(context.TODO(), "jobs", []Indexable{updated, Job{Title: "Designer"}})
+	if err != nil {
+		t.Fatalf("InMemoryRepository.BulkAdd() error = %v", err)
+	}
+	if results[0].Result != ItemUpdated || results[1].Result != ItemCreated {
+		t.Errorf("InMemoryRepository.BulkAdd() = %+v, want updated and created", results)
+	}
+	if count, _ := r.Count(context.TODO(), "jobs", Query{Value: "varejo", Fields: []string{"description"}}); count != 0 {
+		t.Errorf("InMemoryRepository.Count() = %d, previous version is still indexed", count)
+	}
+	if count, _ := r.Count(context.TODO(), "jobs", Query{Value: "seguros", Fields: []string{"description"}}); count != 1 {
+		t.Errorf("InMemoryRepository.Count() = %d, want 1", count)
+	}
+}
+
+func TestInMemoryRepository_Percolate(t *testing.T) {
+	r := newInMemoryRepository()
+	r.AddPercolator(context.TODO(), "alerts", "java", Query{Value: "java", Fields: []string{"title"}, Operator: "and"})
+	r.AddPercolator(context.TODO(), "alerts", "sc", Query{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}})
+	r.AddPercolator(context.TODO(), "alerts", "removed", Query{Bool: &Bool{}})
+	if err := r.Delete(context.TODO(), "alerts", percolatorType, "removed"); err != nil {
+		t.Fatalf("InMemoryRepository.Delete() error = %v", err)
+	}
+
+	got, err := r.Percolate(context.TODO(), "alerts", []Indexable{memoryJobs[0], memoryJobs[1], memoryJobs[3]})
+	if err != nil {
+		t.Fatalf("InMemoryRepository.Percolate() error = %v", err)
+	}
+	if want := [][]string{{"java", "sc"}, nil, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InMemoryRepository.Percolate() = %v, want %v", got, want)
+	}
+}
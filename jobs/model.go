@@ -4,15 +4,120 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Job representation of job
 type Job struct {
-	Title         string   `json:"title,omitempty"`
-	Description   string   `json:"description,omitempty"`
-	Salary        float64  `json:"salario,omitempty"`
-	City          []string `json:"cidade,omitempty"`
-	CityFormatted []string `json:"cidadeFormated,omitempty"`
+	Title         string     `json:"title,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	Salary        float64    `json:"salario,omitempty"`
+	City          []string   `json:"cidade,omitempty"`
+	CityFormatted []string   `json:"cidadeFormated,omitempty"`
+	Locations     []Location `json:"locations,omitempty"`
+}
+
+// Location city, state and number of openings parsed from cidadeFormated, eg. "Joinville - SC (1)"
+type Location struct {
+	City     string `json:"city"`
+	State    string `json:"state"`
+	Openings int    `json:"openings"`
+}
+
+// JobHit job found on repository with its ID, search score and highlight fragments by field
+type JobHit struct {
+	ID         string              `json:"id"`
+	Score      *float64            `json:"score,omitempty"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+	Job
+}
+
+// JobSearch options for searching jobs, Page starts on 1, nil Page and Size use the defaults, and Cursor is the 'next' value from a previous search.
+// Query uses the query language, Fuzziness is 'auto' or the max edit distance for words on query, content and city, SimilarTo is the id of a job to find similar ones
+type JobSearch struct {
+	Query     string
+	Content   string
+	City      string
+	SalaryMin *float64
+	SalaryMax *float64
+	Facets    []string
+	Buckets   []float64
+	State     string
+	CityExact string
+	Fuzziness string
+	SimilarTo string
+	Sort      string
+	Highlight bool
+	Page      *int
+	Size      *int
+	Cursor    string
+}
+
+// JobSearchResult page of jobs found on search and facets counts of all matches
+type JobSearchResult struct {
+	Total  int64                    `json:"total"`
+	Page   int                      `json:"page"`
+	Size   int                      `json:"size"`
+	Next   string                   `json:"next,omitempty"`
+	Jobs   []JobHit                 `json:"jobs"`
+	Facets map[string][]FacetBucket `json:"facets,omitempty"`
+}
+
+// DeleteByQueryResult result of removing all jobs matching a query
+type DeleteByQueryResult struct {
+	Matched int64 `json:"matched"`
+	Deleted int64 `json:"deleted"`
+	DryRun  bool  `json:"dryRun"`
+}
+
+// SavedSearch named search criteria stored to be executed later, pagination and highlight are given on each execution
+type SavedSearch struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"q,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	City      string    `json:"city,omitempty"`
+	CityExact string    `json:"city_exact,omitempty"`
+	State     string    `json:"state,omitempty"`
+	SalaryMin *float64  `json:"salary_min,omitempty"`
+	SalaryMax *float64  `json:"salary_max,omitempty"`
+	Fuzziness string    `json:"fuzzy,omitempty"`
+	Facets    []string  `json:"facets,omitempty"`
+	Buckets   []float64 `json:"salary_buckets,omitempty"`
+	Sort      string    `json:"sort,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SavedSearchList page of saved searches, newest first
+type SavedSearchList struct {
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	Size     int           `json:"size"`
+	Searches []SavedSearch `json:"searches"`
+}
+
+// JobSearch search with saved criteria
+func (s SavedSearch) JobSearch() JobSearch {
+	return JobSearch{
+		Query:     s.Query,
+		Content:   s.Content,
+		City:      s.City,
+		CityExact: s.CityExact,
+		State:     s.State,
+		SalaryMin: s.SalaryMin,
+		SalaryMax: s.SalaryMax,
+		Fuzziness: s.Fuzziness,
+		Facets:    s.Facets,
+		Buckets:   s.Buckets,
+		Sort:      s.Sort,
+	}
+}
+
+// Alert new jobs matching a saved search
+type Alert struct {
+	SearchID string   `json:"searchId"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Jobs     []JobHit `json:"jobs"`
 }
 
 // ID from job using title, salary and city
@@ -20,13 +125,61 @@ func (j Job) ID() string {
 	return createID(j.Title, strconv.FormatFloat(j.Salary, 'f', 2, 64), strings.Join(j.City, " "))
 }
 
+// IngestionStatus status of an ingestion
+type IngestionStatus string
+
+// IngestionStatus values
+const (
+	IngestionQueued     IngestionStatus = "queued"
+	IngestionInProgress IngestionStatus = "in_progress"
+	IngestionDone       IngestionStatus = "done"
+	IngestionFailed     IngestionStatus = "failed"
+)
+
+// Ingestion tracking of a batch of jobs submitted to be indexed
+type Ingestion struct {
+	ID        string           `json:"id"`
+	Tenant    string           `json:"tenant,omitempty"`
+	Status    IngestionStatus  `json:"status"`
+	Total     int              `json:"total"`
+	Pending   int              `json:"pending"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Failed    int              `json:"failed"`
+	Documents []DocumentResult `json:"docs"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// DocumentResult result of a single job on a bulk operation
+type DocumentResult struct {
+	ID     string     `json:"id"`
+	Result ItemResult `json:"result"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// ItemResult outcome of a single document on a bulk request
+type ItemResult string
+
+// ItemResult values
+const (
+	ItemPending ItemResult = "pending"
+	ItemCreated ItemResult = "created"
+	ItemUpdated ItemResult = "updated"
+	ItemDeleted ItemResult = "deleted"
+	ItemFailed  ItemResult = "failed"
+)
+
 const (
 	JOB0000 string = "JOB0000" //unknown
 	JOB1001 string = "JOB1001" //invalid
 	JOB1002 string = "JOB1002" //not found
 	JOB1003 string = "JOB1003" //parser error
+	JOB1004 string = "JOB1004" //unauthorized
+	JOB1005 string = "JOB1005" //unavailable, try again later
 	JOB2001 string = "JOB2001" //connect elastic
 	JOB2002 string = "JOB2002" //access elastic
+	JOB2003 string = "JOB2003" //access disk
 )
 
 type JobError struct {
@@ -46,6 +199,10 @@ const (
 	ERROR_NOT_FOUND
 	ERROR_PARSER
 	ERROR_ELASTIC_SEARCH
+	ERROR_DISK
+	ERROR_UNAUTHORIZED
+	ERROR_FORBIDDEN
+	ERROR_UNAVAILABLE
 )
 
 //NewJobErrorr JobError constructor
@@ -86,6 +243,21 @@ func NewParserError(msg string) *JobError {
 	return newJobError(JOB1003, msg, ERROR_PARSER)
 }
 
+//NewUnauthorizedError constructor unauthorized request, credentials missing or invalid
+func NewUnauthorizedError(msg string) *JobError {
+	return newJobError(JOB1004, msg, ERROR_UNAUTHORIZED)
+}
+
+//NewForbiddenError constructor unauthorized request, valid credentials without permission
+func NewForbiddenError(msg string) *JobError {
+	return newJobError(JOB1004, msg, ERROR_FORBIDDEN)
+}
+
+//NewUnavailableError constructor unavailable error, the request can be sent again later
+func NewUnavailableError(msg string) *JobError {
+	return newJobError(JOB1005, msg, ERROR_UNAVAILABLE)
+}
+
 //NewElasticsearchConnectError constructor elasticsearch connect error
 func NewElasticsearchConnectError(msg string) *JobError {
 	return newJobError(JOB2001, msg, ERROR_ELASTIC_SEARCH)
@@ -95,3 +267,8 @@ func NewElasticsearchConnectError(msg string) *JobError {
 func NewElasticsearchAccessError(msg string) *JobError {
 	return newJobError(JOB2002, msg, ERROR_ELASTIC_SEARCH)
 }
+
+//NewDiskAccessError constructor disk repository access error
+func NewDiskAccessError(msg string) *JobError {
+	return newJobError(JOB2003, msg, ERROR_DISK)
+}
@@ -0,0 +1,319 @@
+package jobs
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// query language operators, keywords are case sensitive so 'and', 'or' and 'not' are searched as words
+const (
+	queryAnd = "AND"
+	queryOr  = "OR"
+	queryNot = "NOT"
+)
+
+// queryField maps a query language field to the job fields it searches, Fields for text, Path and Term for exact values or Range for numbers
+type queryField struct {
+	Fields []string
+	Path   string
+	Term   string
+	Range  string
+	Upper  bool
+}
+
+// jobQueryFields fields accepted on the query language, terms without field search on content
+var jobQueryFields = map[string]queryField{
+	"content":     {Fields: []string{"title^3", "description"}},
+	"title":       {Fields: []string{"title"}},
+	"description": {Fields: []string{"description"}},
+	"city":        {Fields: []string{"cidade"}},
+	"city_exact":  {Path: "locations", Term: "locations.city"},
+	"state":       {Path: "locations", Term: "locations.state", Upper: true},
+	"salary":      {Range: "salario"},
+}
+
+type queryTokenType int
+
+const (
+	tokenEOF queryTokenType = iota
+	tokenWord
+	tokenPhrase
+	tokenField
+	tokenMinus
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	Type   queryTokenType
+	Value  string
+	Column int
+}
+
+// lexQuery splits input on tokens, columns are 1-based and count characters, not bytes
+func lexQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r, column := runes[i], i+1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{Type: tokenOpen, Value: "(", Column: column})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{Type: tokenClose, Value: ")", Column: column})
+			i++
+		case r == '-':
+			tokens = append(tokens, queryToken{Type: tokenMinus, Value: "-", Column: column})
+			i++
+		case r == ':':
+			return nil, newQueryError(column, "unexpected ':'")
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, newQueryError(column, "unterminated phrase")
+			}
+			tokens = append(tokens, queryToken{Type: tokenPhrase, Value: string(runes[i+1 : end]), Column: column})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !isQueryDelimiter(runes[end]) {
+				end++
+			}
+			if end < len(runes) && runes[end] == ':' {
+				tokens = append(tokens, queryToken{Type: tokenField, Value: string(runes[i:end]), Column: column})
+				end++
+			} else {
+				tokens = append(tokens, queryToken{Type: tokenWord, Value: string(runes[i:end]), Column: column})
+			}
+			i = end
+		}
+	}
+	return append(tokens, queryToken{Type: tokenEOF, Column: len(runes) + 1}), nil
+}
+
+func isQueryDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' || r == ':'
+}
+
+// queryParser recursive descent parser of the query language:
+//
+//	query   = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = ("-" | "NOT") unary | primary
+//	primary = "(" query ")" | field ":" primary | word | phrase
+type queryParser struct {
+	tokens    []queryToken
+	pos       int
+	fuzziness string
+}
+
+// parseJobQuery parses the query language to a job Query, errors are invalid request errors with the column of the problem
+func parseJobQuery(input, fuzziness string) (*Query, error) {
+	tokens, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens, fuzziness: fuzziness}
+	query, err := p.parseOr("content")
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Type != tokenEOF {
+		return nil, newQueryError(t.Column, fmt.Sprintf("unexpected '%s'", t.Value))
+	}
+	return query, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.pos]
+	if t.Type != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) isKeyword(t queryToken, keyword string) bool {
+	return t.Type == tokenWord && t.Value == keyword
+}
+
+func (p *queryParser) parseOr(field string) (*Query, error) {
+	query, err := p.parseAnd(field)
+	if err != nil {
+		return nil, err
+	}
+	clauses := []Query{*query}
+	for p.isKeyword(p.peek(), queryOr) {
+		p.next()
+		query, err := p.parseAnd(field)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, *query)
+	}
+	if len(clauses) == 1 {
+		return &clauses[0], nil
+	}
+	return &Query{Bool: &Bool{Should: clauses}}, nil
+}
+
+func (p *queryParser) parseAnd(field string) (*Query, error) {
+	var must, mustNot []Query
+	for {
+		negate := false
+		for t := p.peek(); t.Type == tokenMinus || p.isKeyword(t, queryNot); t = p.peek() {
+			p.next()
+			negate = !negate
+		}
+		query, err := p.parsePrimary(field)
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			mustNot = append(mustNot, *query)
+		} else {
+			must = append(must, *query)
+		}
+
+		t := p.peek()
+		if p.isKeyword(t, queryAnd) {
+			p.next()
+			continue
+		}
+		if t.Type == tokenEOF || t.Type == tokenClose || p.isKeyword(t, queryOr) {
+			break
+		}
+	}
+	if len(must) == 1 && len(mustNot) == 0 {
+		return &must[0], nil
+	}
+	return &Query{Bool: &Bool{Must: must, MustNot: mustNot}}, nil
+}
+
+func (p *queryParser) parsePrimary(field string) (*Query, error) {
+	t := p.next()
+	switch {
+	case t.Type == tokenOpen:
+		query, err := p.parseOr(field)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.Type != tokenClose {
+			return nil, newQueryError(closing.Column, fmt.Sprintf("missing ')' for '(' at column %d", t.Column))
+		}
+		return query, nil
+	case t.Type == tokenField:
+		if _, ok := jobQueryFields[t.Value]; !ok {
+			return nil, newQueryError(t.Column, fmt.Sprintf("unknown field: %s, use one of: %s", t.Value, strings.Join(queryFieldNames(), ", ")))
+		}
+		return p.parsePrimary(t.Value)
+	case t.Type == tokenPhrase:
+		return p.createLeaf(field, t, true)
+	case t.Type == tokenWord && t.Value != queryAnd && t.Value != queryOr && t.Value != queryNot:
+		return p.createLeaf(field, t, false)
+	case t.Type == tokenEOF:
+		return nil, newQueryError(t.Column, "expected a term, found end of query")
+	default:
+		return nil, newQueryError(t.Column, fmt.Sprintf("expected a term, found '%s'", t.Value))
+	}
+}
+
+// createLeaf creates the query of a word or phrase on field
+func (p *queryParser) createLeaf(field string, t queryToken, phrase bool) (*Query, error) {
+	f := jobQueryFields[field]
+	switch {
+	case f.Range != "":
+		if phrase {
+			return nil, newQueryError(t.Column, fmt.Sprintf("invalid value for %s: \"%s\", use a non negative number, >n, >=n, <n, <=n or n..m", field, t.Value))
+		}
+		r, err := parseQueryRange(t.Value)
+		if err != nil {
+			return nil, newQueryError(t.Column, fmt.Sprintf("invalid value for %s: %s, use a non negative number, >n, >=n, <n, <=n or n..m", field, t.Value))
+		}
+		r.Field = f.Range
+		return &Query{Range: r}, nil
+	case f.Term != "":
+		value := t.Value
+		if f.Upper {
+			value = strings.ToUpper(value)
+		}
+		return &Query{Term: &Term{Path: f.Path, Field: f.Term, Value: value}}, nil
+	}
+
+	//plain text, simple query string operators on words like 'c++' or 'java*' are searched as text
+	if phrase {
+		return &Query{Value: t.Value, Fields: f.Fields, Operator: "and", Match: MatchPhrase}, nil
+	}
+	return &Query{Value: t.Value, Fields: f.Fields, Operator: "and", Fuzziness: p.fuzziness, Match: MatchWords}, nil
+}
+
+// parseQueryRange parses a number as exact value, a comparison as >n, >=n, <n, <=n or an inclusive range as n..m,
+// numbers must be finite and non negative like salary_min and salary_max params
+func parseQueryRange(value string) (*Range, error) {
+	parse := func(s string) (*float64, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+			return nil, fmt.Errorf("invalid number: %s", s)
+		}
+		return &f, nil
+	}
+
+	var err error
+	r := &Range{}
+	switch {
+	case strings.HasPrefix(value, ">="):
+		r.Min, err = parse(value[2:])
+	case strings.HasPrefix(value, ">"):
+		r.Min, err = parse(value[1:])
+		r.ExcludeMin = true
+	case strings.HasPrefix(value, "<="):
+		r.Max, err = parse(value[2:])
+	case strings.HasPrefix(value, "<"):
+		r.Max, err = parse(value[1:])
+		r.ExcludeMax = true
+	case strings.Contains(value, ".."):
+		bounds := strings.SplitN(value, "..", 2)
+		if r.Min, err = parse(bounds[0]); err == nil {
+			r.Max, err = parse(bounds[1])
+		}
+		if err == nil && *r.Min > *r.Max {
+			err = fmt.Errorf("min greater than max")
+		}
+	default:
+		r.Min, err = parse(value)
+		r.Max = r.Min
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func queryFieldNames() []string {
+	var names []string
+	for name := range jobQueryFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newQueryError(column int, msg string) *JobError {
+	return NewInvalidRequestError(fmt.Sprintf("invalid query at column %d: %s", column, msg))
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseJobQuery(t *testing.T) {
+	content := []string{"title^3", "description"}
+	tests := []struct {
+		name      string
+		input     string
+		fuzziness string
+		want      *Query
+		wantErr   string
+	}{
+		{"word", "java", "", &Query{Value: "java", Fields: content, Operator: "and", Match: MatchWords}, ""},
+		{"fuzzy word", "java", "AUTO", &Query{Value: "java", Fields: content, Operator: "and", Fuzziness: "AUTO", Match: MatchWords}, ""},
+		{"phrase", `"analista de sistemas"`, "", &Query{Value: "analista de sistemas", Fields: content, Operator: "and", Match: MatchPhrase}, ""},
+		{"implicit and", "java senior", "", &Query{Bool: &Bool{Must: []Query{{Value: "java", Fields: content, Operator: "and", Match: MatchWords}, {Value: "senior", Fields: content, Operator: "and", Match: MatchWords}}}}, ""},
+		{"or", "java OR golang", "", &Query{Bool: &Bool{Should: []Query{{Value: "java", Fields: content, Operator: "and", Match: MatchWords}, {Value: "golang", Fields: content, Operator: "and", Match: MatchWords}}}}, ""},
+		{"lowercase keywords are words", "java or golang", "", &Query{Bool: &Bool{Must: []Query{{Value: "java", Fields: content, Operator: "and", Match: MatchWords}, {Value: "or", Fields: content, Operator: "and", Match: MatchWords}, {Value: "golang", Fields: content, Operator: "and", Match: MatchWords}}}}, ""},
+		{"and binds tighter than or", "a b OR c", "", &Query{Bool: &Bool{Should: []Query{{Bool: &Bool{Must: []Query{{Value: "a", Fields: content, Operator: "and", Match: MatchWords}, {Value: "b", Fields: content, Operator: "and", Match: MatchWords}}}}, {Value: "c", Fields: content, Operator: "and", Match: MatchWords}}}}, ""},
+		{"field group", "title:(java OR golang)", "", &Query{Bool: &Bool{Should: []Query{{Value: "java", Fields: []string{"title"}, Operator: "and", Match: MatchWords}, {Value: "golang", Fields: []string{"title"}, Operator: "and", Match: MatchWords}}}}, ""},
+		{"negation", "java -description:estágio NOT city:joinville", "", &Query{Bool: &Bool{Must: []Query{{Value: "java", Fields: content, Operator: "and", Match: MatchWords}}, MustNot: []Query{{Value: "estágio", Fields: []string{"description"}, Operator: "and", Match: MatchWords}, {Value: "joinville", Fields: []string{"cidade"}, Operator: "and", Match: MatchWords}}}}, ""},
+		{"hyphenated word", "front-end", "", &Query{Value: "front-end", Fields: content, Operator: "and", Match: MatchWords}, ""},
+		{"simple query string operators are words", "title:foo|bar c++ java*", "", &Query{Bool: &Bool{Must: []Query{{Value: "foo|bar", Fields: []string{"title"}, Operator: "and", Match: MatchWords}, {Value: "c++", Fields: content, Operator: "and", Match: MatchWords}, {Value: "java*", Fields: content, Operator: "and", Match: MatchWords}}}}, ""},
+		{"state and exact city", `state:sc city_exact:"São José"`, "", &Query{Bool: &Bool{Must: []Query{{Term: &Term{Path: "locations", Field: "locations.state", Value: "SC"}}, {Term: &Term{Path: "locations", Field: "locations.city", Value: "São José"}}}}}, ""},
+		{"salary greater", "salary:>3000", "", &Query{Range: &Range{Field: "salario", Min: floatPtr(3000), ExcludeMin: true}}, ""},
+		{"salary at most", "salary:<=3000", "", &Query{Range: &Range{Field: "salario", Max: floatPtr(3000)}}, ""},
+		{"salary range", "salary:1000..3000.5", "", &Query{Range: &Range{Field: "salario", Min: floatPtr(1000), Max: floatPtr(3000.5)}}, ""},
+		{"salary exact", "salary:3000", "", &Query{Range: &Range{Field: "salario", Min: floatPtr(3000), Max: floatPtr(3000)}}, ""},
+		{"example", `title:(java OR golang) AND city:"Blumenau" AND salary:>3000 -description:estágio`, "", &Query{Bool: &Bool{
+			Must: []Query{
+				{Bool: &Bool{Should: []Query{{Value: "java", Fields: []string{"title"}, Operator: "and", Match: MatchWords}, {Value: "golang", Fields: []string{"title"}, Operator: "and", Match: MatchWords}}}},
+				{Value: "Blumenau", Fields: []string{"cidade"}, Operator: "and", Match: MatchPhrase},
+				{Range: &Range{Field: "salario", Min: floatPtr(3000), ExcludeMin: true}},
+			},
+			MustNot: []Query{{Value: "estágio", Fields: []string{"description"}, Operator: "and", Match: MatchWords}},
+		}}, ""},
+		{"unknown field", "java salario:3000", "", nil, "invalid query at column 6: unknown field: salario, use one of: city, city_exact, content, description, salary, state, title"},
+		{"invalid salary", "salary:>abc", "", nil, "invalid query at column 8: invalid value for salary: >abc, use a non negative number, >n, >=n, <n, <=n or n..m"},
+		{"not a number salary", "salary:NaN", "", nil, "invalid query at column 8: invalid value for salary: NaN, use a non negative number, >n, >=n, <n, <=n or n..m"},
+		{"infinite salary", "salary:<=Inf", "", nil, "invalid query at column 8: invalid value for salary: <=Inf, use a non negative number, >n, >=n, <n, <=n or n..m"},
+		{"negative salary", "salary:>-500", "", nil, "invalid query at column 8: invalid value for salary: >-500, use a non negative number, >n, >=n, <n, <=n or n..m"},
+		{"inverted salary range", "salary:3000..1000", "", nil, "invalid query at column 8: invalid value for salary: 3000..1000, use a non negative number, >n, >=n, <n, <=n or n..m"},
+		{"unterminated phrase", `estágio "de sistemas`, "", nil, "invalid query at column 9: unterminated phrase"},
+		{"missing paren", "title:(java OR golang", "", nil, "invalid query at column 22: missing ')' for '(' at column 7"},
+		{"unexpected paren", "java)", "", nil, "invalid query at column 5: unexpected ')'"},
+		{"dangling operator", "java AND", "", nil, "invalid query at column 9: expected a term, found end of query"},
+		{"leading operator", "OR java", "", nil, "invalid query at column 1: expected a term, found 'OR'"},
+		{"empty group", "()", "", nil, "invalid query at column 2: expected a term, found ')'"},
+		{"stray colon", "java :", "", nil, "invalid query at column 6: unexpected ':'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJobQuery(tt.input, tt.fuzziness)
+			if tt.wantErr != "" {
+				jobErr, ok := err.(*JobError)
+				if !ok || jobErr.ErrCode != JOB1001 || jobErr.Message != tt.wantErr {
+					t.Errorf("parseJobQuery() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("parseJobQuery() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJobQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
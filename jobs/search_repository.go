@@ -0,0 +1,208 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SearchRepository access and update saved searches
+type SearchRepository interface {
+	Add(ctx context.Context, search SavedSearch) error
+	Get(ctx context.Context, id string) (*SavedSearch, error)
+	List(ctx context.Context, page, size int) (*SavedSearchList, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// savedSearchMapping only id, name and creation date are indexed, criteria are kept on source
+const savedSearchMapping = `{
+	"mappings": {
+		"savedsearch": {
+			"_all": {"enabled": false},
+			"dynamic": false,
+			"properties": {
+				"id": {"type": "keyword"},
+				"name": {"type": "keyword"},
+				"createdAt": {"type": "date"}
+			}
+		}
+	}
+}`
+
+// savedSearch indexable saved search
+type savedSearch struct {
+	SavedSearch
+}
+
+// ID from saved search
+func (s savedSearch) ID() string {
+	return s.SavedSearch.ID
+}
+
+// ElasticSearchSearchRepository SearchRepository impl for elastic search, saved searches are kept on the 'searches' index of each tenant
+type ElasticSearchSearchRepository struct {
+	repository  Repository
+	initialized map[string]bool
+	rmutex      sync.RWMutex
+}
+
+// newElasticSearchSearchRepository ElasticSearchSearchRepository constructor
+func newElasticSearchSearchRepository(elasticSearch Repository) SearchRepository {
+	return &ElasticSearchSearchRepository{repository: elasticSearch, initialized: make(map[string]bool)}
+}
+
+// index searches index of the tenant on ctx, initialized on first use
+func (r *ElasticSearchSearchRepository) index(ctx context.Context) (string, error) {
+	index := tenantIndex(ctx, "searches")
+	r.rmutex.RLock()
+	initialized := r.initialized[index]
+	r.rmutex.RUnlock()
+
+	if !initialized {
+		r.rmutex.Lock()
+		defer r.rmutex.Unlock()
+		if r.initialized[index] { //initialized by another request while waiting
+			return index, nil
+		}
+		if err := r.repository.InitIndex(ctx, index, savedSearchMapping); err != nil {
+			return "", err
+		}
+		r.initialized[index] = true
+	}
+	return index, nil
+}
+
+// Add stores saved search
+func (r *ElasticSearchSearchRepository) Add(ctx context.Context, search SavedSearch) error {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return err
+	}
+	return r.repository.Add(ctx, index, savedSearch{search})
+}
+
+// Get finds saved search by id
+func (r *ElasticSearchSearchRepository) Get(ctx context.Context, id string) (*SavedSearch, error) {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.repository.Get(ctx, index, id)
+	if err != nil {
+		if jobErr, ok := err.(*JobError); ok && jobErr.Type() == ERROR_NOT_FOUND {
+			return nil, NewNotFoundError(fmt.Sprintf("search %s not found", id))
+		}
+		return nil, err
+	}
+	var search SavedSearch
+	if err := json.Unmarshal(result, &search); err != nil {
+		return nil, NewParserError(fmt.Sprintf("error parsing saved search, message: %s", err.Error()))
+	}
+	return &search, nil
+}
+
+// List finds saved searches, newest first
+func (r *ElasticSearchSearchRepository) List(ctx context.Context, page, size int) (*SavedSearchList, error) {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.repository.Search(ctx, index, []Sort{{Field: "createdAt"}}, &Page{From: (page - 1) * size, Size: size}, nil, nil, Query{Bool: &Bool{}})
+	if err != nil {
+		return nil, err
+	}
+
+	list := &SavedSearchList{Total: result.Total, Page: page, Size: size, Searches: []SavedSearch{}}
+	for _, hit := range result.Hits {
+		var search SavedSearch
+		if err := json.Unmarshal(hit.Source, &search); err != nil {
+			return nil, NewParserError(fmt.Sprintf("error parsing saved search, message: %s", err.Error()))
+		}
+		list.Searches = append(list.Searches, search)
+	}
+	return list, nil
+}
+
+// Delete removes saved search by id
+func (r *ElasticSearchSearchRepository) Delete(ctx context.Context, id string) error {
+	index, err := r.index(ctx) //lazy index initialization
+	if err != nil {
+		return err
+	}
+	if err := r.repository.Delete(ctx, index, indexType(savedSearch{}), id); err != nil {
+		if jobErr, ok := err.(*JobError); ok && jobErr.Type() == ERROR_NOT_FOUND {
+			return NewNotFoundError(fmt.Sprintf("search %s not found", id))
+		}
+		return err
+	}
+	return nil
+}
+
+// MemorySearchRepository SearchRepository impl in memory
+type MemorySearchRepository struct {
+	searches map[string]SavedSearch
+	rmutex   sync.RWMutex
+}
+
+// newMemorySearchRepository MemorySearchRepository constructor
+func newMemorySearchRepository() *MemorySearchRepository {
+	return &MemorySearchRepository{searches: make(map[string]SavedSearch)}
+}
+
+// Add stores saved search
+func (r *MemorySearchRepository) Add(ctx context.Context, search SavedSearch) error {
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	r.searches[search.ID] = search
+	return nil
+}
+
+// Get finds saved search by id
+func (r *MemorySearchRepository) Get(ctx context.Context, id string) (*SavedSearch, error) {
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+	search, ok := r.searches[id]
+	if !ok {
+		return nil, NewNotFoundError(fmt.Sprintf("search %s not found", id))
+	}
+	return &search, nil
+}
+
+// List finds saved searches, newest first
+func (r *MemorySearchRepository) List(ctx context.Context, page, size int) (*SavedSearchList, error) {
+	r.rmutex.RLock()
+	defer r.rmutex.RUnlock()
+	searches := make([]SavedSearch, 0, len(r.searches))
+	for _, search := range r.searches {
+		searches = append(searches, search)
+	}
+	sort.Slice(searches, func(i, j int) bool {
+		if searches[i].CreatedAt.Equal(searches[j].CreatedAt) {
+			return searches[i].ID < searches[j].ID
+		}
+		return searches[i].CreatedAt.After(searches[j].CreatedAt)
+	})
+
+	list := &SavedSearchList{Total: int64(len(searches)), Page: page, Size: size, Searches: []SavedSearch{}}
+	if from := (page - 1) * size; from < len(searches) {
+		to := from + size
+		if to > len(searches) {
+			to = len(searches)
+		}
+		list.Searches = searches[from:to]
+	}
+	return list, nil
+}
+
+// Delete removes saved search by id
+func (r *MemorySearchRepository) Delete(ctx context.Context, id string) error {
+	r.rmutex.Lock()
+	defer r.rmutex.Unlock()
+	if _, ok := r.searches[id]; !ok {
+		return NewNotFoundError(fmt.Sprintf("search %s not found", id))
+	}
+	delete(r.searches, id)
+	return nil
+}
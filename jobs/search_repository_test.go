@@ -0,0 +1,172 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestElasticSearchSearchRepository_Get(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   json.RawMessage
+		err      error
+		want     *SavedSearch
+		wantCode string
+	}{
+		{"not found", nil, NewNotFoundError("id abc not found"), nil, JOB1002},
+		{"error", nil, NewElasticsearchAccessError("error"), nil, JOB2002},
+		{"invalid source", json.RawMessage(`[]`), nil, nil, JOB1003},
+		{"success", json.RawMessage(`{"id":"abc","name":"java jobs","q":"title:java","salary_min":3000,"createdAt":"2017-01-26T02:04:51Z"}`), nil, &SavedSearch{ID: "abc", Name: "java jobs", Query: "title:java", SalaryMin: floatPtr(3000), CreatedAt: time.Date(2017, 1, 26, 2, 4, 51, 0, time.UTC)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newElasticSearchSearchRepository(&mockRepository{getFn: func() (json.RawMessage, error) { return tt.result, tt.err }})
+			got, err := r.Get(context.TODO(), "abc")
+			if tt.wantCode != "" {
+				if jobErr, ok := err.(*JobError); !ok || jobErr.ErrCode != tt.wantCode {
+					t.Errorf("ElasticSearchSearchRepository.Get() error = %v, want code %v", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ElasticSearchSearchRepository.Get() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearchSearchRepository.Get() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticSearchSearchRepository_List(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     int
+		size     int
+		initErr  error
+		result   *SearchResult
+		err      error
+		want     *SavedSearchList
+		wantPage *Page
+		wantErr  bool
+	}{
+		{"init error", 1, 10, errors.New("error"), nil, nil, nil, nil, true},
+		{"search error", 1, 10, nil, nil, errors.New("error"), nil, &Page{From: 0, Size: 10}, true},
+		{"empty", 2, 5, nil, &SearchResult{Total: 3}, nil, &SavedSearchList{Total: 3, Page: 2, Size: 5, Searches: []SavedSearch{}}, &Page{From: 5, Size: 5}, false},
+		{"success", 1, 10, nil, &SearchResult{Total: 1, Hits: []SearchHit{{ID: "abc", Source: json.RawMessage(`{"id":"abc","name":"java jobs","createdAt":"2017-01-26T02:04:51Z"}`)}}}, nil, &SavedSearchList{Total: 1, Page: 1, Size: 10, Searches: []SavedSearch{{ID: "abc", Name: "java jobs", CreatedAt: time.Date(2017, 1, 26, 2, 4, 51, 0, time.UTC)}}}, &Page{From: 0, Size: 10}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPage *Page
+			r := newElasticSearchSearchRepository(&mockRepository{initFn: func() error { return tt.initErr }, searchFn: func(page *Page) (*SearchResult, error) {
+				gotPage = page
+				return tt.result, tt.err
+			}})
+			got, err := r.List(context.TODO(), tt.page, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ElasticSearchSearchRepository.List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ElasticSearchSearchRepository.List() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotPage, tt.wantPage) {
+				t.Errorf("ElasticSearchSearchRepository.List() page = %+v, want %+v", gotPage, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestElasticSearchSearchRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{"not found", NewNotFoundError("id abc not found"), JOB1002},
+		{"error", NewElasticsearchAccessError("error"), JOB2002},
+		{"success", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newElasticSearchSearchRepository(&mockRepository{initFn: func() error { return nil }, deleteFn: func() error { return tt.err }})
+			err := r.Delete(context.TODO(), "abc")
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("ElasticSearchSearchRepository.Delete() error = %v", err)
+				}
+				return
+			}
+			if jobErr, ok := err.(*JobError); !ok || jobErr.ErrCode != tt.wantCode {
+				t.Errorf("ElasticSearchSearchRepository.Delete() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func Test_savedSearch_ID(t *testing.T) {
+	search := savedSearch{SavedSearch{ID: "abc", Name: "java jobs"}}
+	if got := search.ID(); got != "abc" {
+		t.Errorf("savedSearch.ID() = %v, want %v", got, "abc")
+	}
+	if got := indexType(search); got != "savedsearch" {
+		t.Errorf("indexType() = %v, want %v", got, "savedsearch")
+	}
+}
+
+func TestMemorySearchRepository(t *testing.T) {
+	now := time.Date(2017, 1, 26, 2, 4, 51, 0, time.UTC)
+	r := newMemorySearchRepository()
+	for i, id := range []string{"a", "b", "c"} {
+		if err := r.Add(context.TODO(), SavedSearch{ID: id, Name: id, CreatedAt: now.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("MemorySearchRepository.Add() error = %v", err)
+		}
+	}
+
+	got, err := r.Get(context.TODO(), "b")
+	if err != nil || got.Name != "b" {
+		t.Errorf("MemorySearchRepository.Get() = %+v, %v, want b", got, err)
+	}
+	if _, err := r.Get(context.TODO(), "d"); err == nil {
+		t.Errorf("MemorySearchRepository.Get() expected not found error")
+	}
+
+	tests := []struct {
+		name    string
+		page    int
+		size    int
+		wantIDs []string
+	}{
+		{"newest first", 1, 2, []string{"c", "b"}},
+		{"last page", 2, 2, []string{"a"}},
+		{"after last page", 3, 2, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := r.List(context.TODO(), tt.page, tt.size)
+			if err != nil {
+				t.Errorf("MemorySearchRepository.List() error = %v", err)
+				return
+			}
+			ids := []string{}
+			for _, search := range list.Searches {
+				ids = append(ids, search.ID)
+			}
+			if list.Total != 3 || !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("MemorySearchRepository.List() = %d %v, want 3 %v", list.Total, ids, tt.wantIDs)
+			}
+		})
+	}
+
+	if err := r.Delete(context.TODO(), "b"); err != nil {
+		t.Errorf("MemorySearchRepository.Delete() error = %v", err)
+	}
+	if err := r.Delete(context.TODO(), "b"); err == nil {
+		t.Errorf("MemorySearchRepository.Delete() expected not found error")
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTenant tenant of requests without tenant, its indexes have no suffix, eg. 'jobs'
+const DefaultTenant = ""
+
+var tenantName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// ParseTenants parses comma separated tenant names, names have lower case letters, digits and '-' with at most 32 characters
+func ParseTenants(value string) (map[string]bool, error) {
+	tenants := make(map[string]bool)
+	for _, tenant := range strings.Split(value, ",") {
+		tenant = strings.TrimSpace(tenant)
+		if tenant == "" {
+			continue
+		}
+		if !tenantName.MatchString(tenant) {
+			return nil, fmt.Errorf("invalid tenant: '%s', use lower case letters, digits and '-' with at most 32 characters", tenant)
+		}
+		tenants[tenant] = true
+	}
+	return tenants, nil
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant context with the tenant of the request, see TenantFromContext
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext tenant of the request, DefaultTenant when not set
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// tenantIndex index of the tenant on ctx, the index itself for the default tenant and 'index-tenant' for the others
+func tenantIndex(ctx context.Context, index string) string {
+	if tenant := TenantFromContext(ctx); tenant != DefaultTenant {
+		return index + "-" + tenant
+	}
+	return index
+}
+
+// resolveTenant tenant of a request, the tenant of the credential or the requested one when the credential has no tenant,
+// forbidden when the credential belongs to another tenant and invalid when the tenant is not configured
+func resolveTenant(tenants map[string]bool, credential, requested string) (string, error) {
+	tenant := requested
+	if credential != DefaultTenant {
+		if requested != "" && requested != credential {
+			return "", NewForbiddenError(fmt.Sprintf("credential of tenant %s cannot access tenant %s", credential, requested))
+		}
+		tenant = credential
+	}
+	if tenant != DefaultTenant && !tenants[tenant] {
+		return "", NewInvalidRequestError(fmt.Sprintf("unknown tenant: %s", tenant))
+	}
+	return tenant, nil
+}
@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseTenants(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{"empty", "", map[string]bool{}, false},
+		{"tenants", "acme, partner-2,", map[string]bool{"acme": true, "partner-2": true}, false},
+		{"upper case", "Acme", nil, true},
+		{"invalid char", "acme_jobs", nil, true},
+		{"starts with dash", "-acme", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTenants(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTenants() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTenants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveTenant(t *testing.T) {
+	tenants := map[string]bool{"acme": true, "globex": true}
+	tests := []struct {
+		name       string
+		credential string
+		requested  string
+		want       string
+		wantError  ErrorType
+	}{
+		{"default", "", "", DefaultTenant, 0},
+		{"requested", "", "acme", "acme", 0},
+		{"credential", "acme", "", "acme", 0},
+		{"credential and same tenant", "acme", "acme", "acme", 0},
+		{"credential of other tenant", "acme", "globex", "", ERROR_FORBIDDEN},
+		{"unknown tenant", "", "initech", "", ERROR_INVALID},
+		{"credential of unknown tenant", "initech", "", "", ERROR_INVALID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTenant(tenants, tt.credential, tt.requested)
+			if tt.wantError != 0 {
+				if jobErr, ok := err.(*JobError); !ok || jobErr.ErrType != tt.wantError {
+					t.Errorf("resolveTenant() error = %v, want type %d", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolveTenant() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// initRecorder Repository recording initialized indexes
+type initRecorder struct {
+	*InMemoryRepository
+	initialized []string
+}
+
+func (r *initRecorder) InitIndex(ctx context.Context, name, mapping string) error {
+	r.initialized = append(r.initialized, name)
+	return r.InMemoryRepository.InitIndex(ctx, name, mapping)
+}
+
+func TestElasticSearchJobRepository_tenants(t *testing.T) {
+	backend := &initRecorder{InMemoryRepository: newInMemoryRepository()}
+	r := newElasticSearchJobRepository(backend, "", Highlight{})
+	acme := ContextWithTenant(context.TODO(), "acme")
+	for _, ctx := range []context.Context{acme, context.TODO(), acme} {
+		if _, err := r.AddAll(ctx, memoryJobs[:1]); err != nil {
+			t.Fatalf("ElasticSearchJobRepository.AddAll() error = %v", err)
+		}
+	}
+	if want := []string{"jobs-acme", "jobs"}; !reflect.DeepEqual(backend.initialized, want) {
+		t.Errorf("ElasticSearchJobRepository.AddAll() initialized = %v, want %v", backend.initialized, want)
+	}
+
+	r.Delete(context.TODO(), memoryJobs[0].ID())
+	if _, err := r.Get(acme, memoryJobs[0].ID()); err != nil {
+		t.Errorf("ElasticSearchJobRepository.Get() tenant job error = %v", err)
+	}
+	if _, err := r.Get(context.TODO(), memoryJobs[0].ID()); err == nil {
+		t.Errorf("ElasticSearchJobRepository.Get() deleted job of default tenant found")
+	}
+}
@@ -2,10 +2,13 @@ package jobs
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/sha1"
 	"encoding/hex"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"golang.org/x/text/runes"
@@ -38,3 +41,31 @@ func createID(values ...string) string {
 	r := regexp.MustCompile("[[:^alnum:]]+")
 	return hash(r.ReplaceAllString(strings.ToLower(buffer.String()), "-"))
 }
+
+var cityFormatted = regexp.MustCompile(`^\s*(.+?)\s*-\s*([[:alpha:]]{2})\s*(?:\((\d+)\))?\s*$`)
+
+// parseLocations parses values like "Joinville - SC (1)" as city, state and openings, openings is 1 when missing, invalid values are ignored
+func parseLocations(values []string) []Location {
+	var locations []Location
+	for _, v := range values {
+		match := cityFormatted.FindStringSubmatch(v)
+		if match == nil {
+			continue
+		}
+		openings := 1
+		if match[3] != "" {
+			openings, _ = strconv.Atoi(match[3])
+		}
+		locations = append(locations, Location{City: match[1], State: strings.ToUpper(match[2]), Openings: openings})
+	}
+	return locations
+}
+
+// newRandomID create a random hex id
+func newRandomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return hash(time.Now().String())
+	}
+	return hex.EncodeToString(b)
+}
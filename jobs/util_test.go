@@ -1,6 +1,9 @@
 package jobs
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func Test_removeAccents(t *testing.T) {
 	tests := []struct {
@@ -55,3 +58,24 @@ func Test_createID(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseLocations(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []Location
+	}{
+		{"empty", nil, nil},
+		{"single", []string{"Joinville - SC (1)"}, []Location{{City: "Joinville", State: "SC", Openings: 1}}},
+		{"multiple with spaces", []string{"São José dos Campos - SP (12)", "Porto Alegre-rs(2)"}, []Location{{City: "São José dos Campos", State: "SP", Openings: 12}, {City: "Porto Alegre", State: "RS", Openings: 2}}},
+		{"no openings", []string{"Canoas - RS"}, []Location{{City: "Canoas", State: "RS", Openings: 1}}},
+		{"invalid ignored", []string{"Joinville", "Blumenau - SC (3)"}, []Location{{City: "Blumenau", State: "SC", Openings: 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLocations(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,18 +27,163 @@ func init() {
 	log.SetFlags(log.Ldate | log.Lmicroseconds)
 }
 
+// legacySearchVersion media type version for the bare array search response
+const legacySearchVersion = "vnd.c-jobs.v1"
+
 func getJobs(jobService *jobs.JobsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		content := r.URL.Query().Get("content")
-		city := r.URL.Query().Get("city")
-		sort := r.URL.Query().Get("sort")
-		jobs, err := jobService.Search(r.Context(), content, city, strings.ToLower(sort) == "asc")
+		search, err := searchParams(r)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		result, err := jobService.Search(r.Context(), search)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		if strings.Contains(r.Header.Get("Accept"), legacySearchVersion) {
+			err = jsonWriter(r.Context(), w, http.StatusOK, legacySearchVersion, result.Jobs)
+		} else {
+			err = jsonWriter(r.Context(), w, http.StatusOK, "", result)
+		}
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func getSimilarJobs(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		search, err := searchParams(r)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		result, err := jobService.Similar(r.Context(), pat.Param(r, "id"), search)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", result)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+// searchParams reads search options from query string
+func searchParams(r *http.Request) (jobs.JobSearch, error) {
+	page, err := intParam(r, "page")
+	if err != nil {
+		return jobs.JobSearch{}, err
+	}
+	size, err := intParam(r, "size")
+	if err != nil {
+		return jobs.JobSearch{}, err
+	}
+	salaryMin, err := floatParam(r, "salary_min")
+	if err != nil {
+		return jobs.JobSearch{}, err
+	}
+	salaryMax, err := floatParam(r, "salary_max")
+	if err != nil {
+		return jobs.JobSearch{}, err
+	}
+	buckets, err := jobs.ParseBuckets(r.URL.Query().Get("salary_buckets"))
+	if err != nil {
+		return jobs.JobSearch{}, err
+	}
+
+	return jobs.JobSearch{
+		Query:     r.URL.Query().Get("q"),
+		Content:   r.URL.Query().Get("content"),
+		City:      r.URL.Query().Get("city"),
+		SalaryMin: salaryMin,
+		SalaryMax: salaryMax,
+		Facets:    listParam(r, "facets"),
+		Buckets:   buckets,
+		State:     r.URL.Query().Get("state"),
+		CityExact: r.URL.Query().Get("city_exact"),
+		Fuzziness: r.URL.Query().Get("fuzzy"),
+		Sort:      r.URL.Query().Get("sort"),
+		Highlight: strings.ToLower(r.URL.Query().Get("highlight")) == "true",
+		Page:      page,
+		Size:      size,
+		Cursor:    r.URL.Query().Get("search_after"),
+	}, nil
+}
+
+func listParam(r *http.Request, name string) []string {
+	var result []string
+	for _, v := range strings.Split(r.URL.Query().Get(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func floatParam(r *http.Request, name string) (*float64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, jobs.NewInvalidRequestError(fmt.Sprintf("invalid value for %s: %s", name, value))
+	}
+	return &f, nil
+}
+
+func intParam(r *http.Request, name string) (*int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, jobs.NewInvalidRequestError(fmt.Sprintf("invalid value for %s: %s", name, value))
+	}
+	return &i, nil
+}
+
+func getJob(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := jobService.Get(r.Context(), pat.Param(r, "id"))
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", job)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func getSuggestions(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		size, err := intParam(r, "size")
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+		suggestions, err := jobService.Suggest(r.Context(), r.URL.Query().Get("field"), r.URL.Query().Get("prefix"), size)
 		if err != nil {
 			errorHandler(r.Context(), w, err)
 			return
 		}
 
-		err = jsonWriter(r.Context(), w, http.StatusOK, "", jobs)
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", suggestResponse{Suggestions: suggestions})
 		if err != nil {
 			errorHandler(r.Context(), w, err)
 			return
@@ -44,10 +191,18 @@ func getJobs(jobService *jobs.JobsService) http.HandlerFunc {
 	}
 }
 
+type suggestResponse struct {
+	Suggestions []jobs.Suggestion `json:"suggestions"`
+}
+
 type jobRequest struct {
 	Jobs []jobs.Job `json:"docs,omitempty"`
 }
 
+type ingestionResponse struct {
+	ID string `json:"id"`
+}
+
 func postJobs(jobService *jobs.JobsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var content jobRequest
@@ -57,7 +212,181 @@ func postJobs(jobService *jobs.JobsService) http.HandlerFunc {
 			return
 		}
 
-		err = jobService.Add(r.Context(), content.Jobs)
+		id, err := jobService.Add(r.Context(), content.Jobs)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusAccepted, "", ingestionResponse{ID: id})
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+type deleteResponse struct {
+	Docs []jobs.DocumentResult `json:"docs"`
+}
+
+func deleteJob(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := jobService.Delete(r.Context(), pat.Param(r, "id"))
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func postDeleteJobs(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var content jobRequest
+		err := jsonReader(r.Context(), r, &content)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		docs, err := jobService.DeleteAll(r.Context(), content.Jobs)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", deleteResponse{Docs: docs})
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func deleteJobs(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content := r.URL.Query().Get("content")
+		city := r.URL.Query().Get("city")
+		dryRun := r.URL.Query().Get("dry_run")
+		result, err := jobService.DeleteByQuery(r.Context(), content, city, strings.ToLower(dryRun) == "true")
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", result)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func getIngestion(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ingestion, err := jobService.Ingestion(r.Context(), pat.Param(r, "id"))
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", ingestion)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func postSearches(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var content jobs.SavedSearch
+		err := jsonReader(r.Context(), r, &content)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		search, err := jobService.SaveSearch(r.Context(), content)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusCreated, "", search)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func getSearches(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := intParam(r, "page")
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+		size, err := intParam(r, "size")
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		list, err := jobService.ListSearches(r.Context(), page, size)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", list)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func getSearchResults(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		search, err := searchParams(r)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		result, err := jobService.SavedSearchResults(r.Context(), pat.Param(r, "id"), search)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		err = jsonWriter(r.Context(), w, http.StatusOK, "", result)
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+	}
+}
+
+func deleteSearch(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := jobService.DeleteSearch(r.Context(), pat.Param(r, "id"))
+		if err != nil {
+			errorHandler(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func postReloadSynonyms(jobService *jobs.JobsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := jobService.ReloadSynonyms(r.Context())
 		if err != nil {
 			errorHandler(r.Context(), w, err)
 			return
@@ -76,15 +405,35 @@ func main() {
 	}
 
 	jobService := jobs.NewJobServices()
+	defer jobService.Close()
+
+	mux := newMux(jobService)
+	log.Printf("message=\"starting server\" kind=startup version=%s", config.Version)
+	defer log.Printf("message=\"stopping server\" kind=startup version=%s", config.Version)
+	gracehttp.Serve(&http.Server{Addr: fmt.Sprintf(":%d", config.Get().Port), Handler: mux})
+}
 
+// newMux routes of the jobs api, specific routes are registered before routes with id
+func newMux(jobService *jobs.JobsService) *goji.Mux {
 	mux := goji.NewMux()
 	mux.Use(notFoundMiddleware)
 	mux.Use(logMiddleware)
+	mux.Use(authMiddleware(jobService))
 	mux.HandleFunc(pat.Get("/jobs"), getJobs(jobService))
+	mux.HandleFunc(pat.Get("/jobs/_suggest"), getSuggestions(jobService))
+	mux.HandleFunc(pat.Get("/jobs/:id"), getJob(jobService))
+	mux.HandleFunc(pat.Get("/jobs/:id/similar"), getSimilarJobs(jobService))
 	mux.HandleFunc(pat.Post("/jobs"), postJobs(jobService))
-	log.Printf("message=\"starting server\" kind=startup version=%s", config.Version)
-	defer log.Printf("message=\"stopping server\" kind=startup version=%s", config.Version)
-	gracehttp.Serve(&http.Server{Addr: fmt.Sprintf(":%d", config.Get().Port), Handler: mux})
+	mux.HandleFunc(pat.Post("/jobs/_delete"), postDeleteJobs(jobService))
+	mux.HandleFunc(pat.Delete("/jobs"), deleteJobs(jobService))
+	mux.HandleFunc(pat.Delete("/jobs/:id"), deleteJob(jobService))
+	mux.HandleFunc(pat.Get("/ingestions/:id"), getIngestion(jobService))
+	mux.HandleFunc(pat.Get("/searches"), getSearches(jobService))
+	mux.HandleFunc(pat.Get("/searches/:id/results"), getSearchResults(jobService))
+	mux.HandleFunc(pat.Post("/searches"), postSearches(jobService))
+	mux.HandleFunc(pat.Delete("/searches/:id"), deleteSearch(jobService))
+	mux.HandleFunc(pat.Post("/admin/synonyms/_reload"), postReloadSynonyms(jobService))
+	return mux
 }
 
 func logMiddleware(inner http.Handler) http.Handler {
@@ -93,11 +442,93 @@ func logMiddleware(inner http.Handler) http.Handler {
 		lrw := newLoggingResponseWriter(w)
 		log.Printf("message=\"request start\" kind=access method=%s path=%s", r.Method, r.URL.RequestURI())
 		inner.ServeHTTP(lrw, r)
-		log.Printf("message=\"request done\" kind=access method=%s path=%s code=%d size=%d duration=%d", r.Method, r.URL.RequestURI(), lrw.statusCode, lrw.size, int64(time.Since(start)/time.Millisecond))
+		log.Printf("message=\"request done\" kind=access method=%s path=%s code=%d size=%d duration=%d subject=\"%s\" tenant=%s", r.Method, r.URL.RequestURI(), lrw.statusCode, lrw.size, int64(time.Since(start)/time.Millisecond), lrw.subject, lrw.tenant)
 	}
 	return http.HandlerFunc(mw)
 }
 
+// auth headers, write requests require a bearer token on Authorization header or an api key on X-API-Key header,
+// X-Tenant selects the tenant of requests with credentials without tenant and of reads
+const (
+	apiKeyHeader        = "X-API-Key"
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+	tenantHeader        = "X-Tenant"
+)
+
+// authMiddleware requires a bearer token or an api key with the scope of the request on write and admin requests, see requiredScope,
+// the authenticated subject and the tenant of the request are set on request context and on access log
+func authMiddleware(jobService *jobs.JobsService) func(http.Handler) http.Handler {
+	return func(inner http.Handler) http.Handler {
+		mw := func(w http.ResponseWriter, r *http.Request) {
+			scope := requiredScope(r)
+			ctx := r.Context()
+			credentialTenant := jobs.DefaultTenant
+			//reads are public on the default tenant, other tenants require a credential of the tenant or without tenant
+			if scope != "" || r.Header.Get(tenantHeader) != jobs.DefaultTenant {
+				subject, tenant, err := authorize(r, jobService, scope)
+				if err != nil {
+					log.Printf("message=\"request rejected\" kind=auth method=%s path=%s scope=%s error=\"%s\"", r.Method, r.URL.Path, scope, err.Error())
+					errorHandler(ctx, w, err)
+					return
+				}
+				if lrw, ok := w.(*loggingResponseWriter); ok {
+					lrw.subject = subject
+				}
+				ctx, credentialTenant = jobs.ContextWithSubject(ctx, subject), tenant
+			}
+
+			tenant, err := jobService.ResolveTenant(ctx, credentialTenant, r.Header.Get(tenantHeader))
+			if err != nil {
+				log.Printf("message=\"request rejected\" kind=auth method=%s path=%s tenant=\"%s\" error=\"%s\"", r.Method, r.URL.Path, r.Header.Get(tenantHeader), err.Error())
+				errorHandler(ctx, w, err)
+				return
+			}
+			if lrw, ok := w.(*loggingResponseWriter); ok {
+				lrw.tenant = tenant
+			}
+			inner.ServeHTTP(w, r.WithContext(jobs.ContextWithTenant(ctx, tenant)))
+		}
+		return http.HandlerFunc(mw)
+	}
+}
+
+// authorize validates the bearer token, when set, or the api key of request with scope,
+// returns the token subject or the api key name and the tenant of the credential
+func authorize(r *http.Request, jobService *jobs.JobsService, scope string) (string, string, error) {
+	if auth := r.Header.Get(authorizationHeader); auth != "" {
+		if len(auth) < len(bearerPrefix) || !strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+			return "", "", jobs.NewUnauthorizedError("authorization header must be a bearer token")
+		}
+		claims, err := jobService.AuthorizeToken(r.Context(), strings.TrimSpace(auth[len(bearerPrefix):]), scope)
+		if err != nil {
+			return "", "", err
+		}
+		return claims.Subject, claims.Tenant, nil
+	}
+	if r.Header.Get(apiKeyHeader) == "" {
+		return "", "", jobs.NewUnauthorizedError("api key or bearer token is required")
+	}
+	key, err := jobService.AuthorizeAPIKey(r.Context(), r.Header.Get(apiKeyHeader), scope)
+	if err != nil {
+		return "", "", err
+	}
+	return key.Name, key.Tenant, nil
+}
+
+// requiredScope scope of request, admin routes require admin, deletes require jobs:delete, other writes jobs:write and reads none
+func requiredScope(r *http.Request) string {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/admin/"):
+		return jobs.ScopeAdmin
+	case r.Method == http.MethodDelete, r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/_delete"):
+		return jobs.ScopeJobsDelete
+	case r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions:
+		return jobs.ScopeJobsWrite
+	}
+	return ""
+}
+
 func notFoundMiddleware(inner http.Handler) http.Handler {
 	mw := func(w http.ResponseWriter, r *http.Request) {
 		if handler := middleware.Handler(r.Context()); handler == nil {
@@ -125,7 +556,13 @@ func getErrorStatusCode(errorType jobs.ErrorType) int {
 		return http.StatusBadRequest
 	case jobs.ERROR_NOT_FOUND:
 		return http.StatusNotFound
-	case jobs.ERROR_ELASTIC_SEARCH, jobs.ERROR_PARSER:
+	case jobs.ERROR_UNAUTHORIZED:
+		return http.StatusUnauthorized
+	case jobs.ERROR_FORBIDDEN:
+		return http.StatusForbidden
+	case jobs.ERROR_UNAVAILABLE:
+		return http.StatusServiceUnavailable
+	case jobs.ERROR_ELASTIC_SEARCH, jobs.ERROR_DISK, jobs.ERROR_PARSER:
 		return http.StatusInternalServerError
 	default:
 		return http.StatusInternalServerError
@@ -167,10 +604,12 @@ type loggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
 	size       int
+	subject    string
+	tenant     string
 }
 
 func newLoggingResponseWriter(w http.ResponseWriter) *loggingResponseWriter {
-	return &loggingResponseWriter{w, http.StatusOK, 0}
+	return &loggingResponseWriter{w, http.StatusOK, 0, "anonymous", jobs.DefaultTenant}
 }
 
 func (lrw *loggingResponseWriter) WriteHeader(code int) {
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvieira/c-jobs/jobs"
+	"github.com/bvieira/c-jobs/jobs/config"
+)
+
+var server *httptest.Server
+
+// api keys configured on the test server, testAPIKey can write and delete jobs and writerAPIKey only write
+const (
+	testAPIKey    = "test-key"
+	writerAPIKey  = "writer-key"
+	testJWTSecret = "0123456789abcdef0123456789abcdef"
+	acmeAPIKey    = "acme-key"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JOBS_REPOSITORY", jobs.RepositoryMemory)
+	os.Setenv("JOBS_ELASTICSEARCH_INDEX_MAPPING_PATH", "../cfg/jobs-mapping.json")
+	os.Setenv("JOBS_ELASTICSEARCH_SYNONYMS_PATH", "../cfg/jobs-synonyms.txt")
+	os.Setenv("JOBS_INGESTION_FLUSH_INTERVAL_MILLISECONDS", "10")
+	os.Setenv("JOBS_ALERT_SINK", "none")
+	os.Setenv("JOBS_JWT_SECRET", testJWTSecret)
+	os.Setenv("JOBS_JWT_AUDIENCE", "c-jobs")
+	os.Setenv("JOBS_JWT_ISSUER", "gateway")
+	os.Setenv("JOBS_API_KEYS", "test:"+jobs.HashAPIKey(testAPIKey)+":jobs:write,jobs:delete;writer:"+jobs.HashAPIKey(writerAPIKey)+":jobs:write;ingester@acme:"+jobs.HashAPIKey(acmeAPIKey)+":jobs:write")
+	os.Setenv("JOBS_TENANTS", "acme,globex")
+	config.Load()
+
+	jobService := jobs.NewJobServices()
+	server = httptest.NewServer(newMux(jobService))
+	code := m.Run()
+	server.Close()
+	jobService.Close()
+	os.Exit(code)
+}
+
+// request sends body as json with testAPIKey and decodes the response on result when set, returns the status code
+func request(t *testing.T, method, path string, body, result interface{}) int {
+	return requestWithKey(t, method, path, testAPIKey, body, result)
+}
+
+// requestWithKey sends body as json with the api key when set and decodes the response on result when set, returns the status code
+func requestWithKey(t *testing.T, method, path, key string, body, result interface{}) int {
+	return requestWithHeaders(t, method, path, map[string]string{apiKeyHeader: key}, body, result)
+}
+
+// requestWithHeaders sends body as json with the headers with value and decodes the response on result when set, returns the status code
+func requestWithHeaders(t *testing.T, method, path string, headers map[string]string, body, result interface{}) int {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	for header, value := range headers {
+		if value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			t.Fatalf("%s %s invalid response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// addJobs posts jobs and waits for the ingestion to finish
+func addJobs(t *testing.T, docs ...jobs.Job) {
+	addTenantJobs(t, map[string]string{apiKeyHeader: testAPIKey}, docs...)
+}
+
+// addTenantJobs posts jobs with the auth and tenant headers and waits for the ingestion to finish
+func addTenantJobs(t *testing.T, headers map[string]string, docs ...jobs.Job) {
+	var ingestion jobs.Ingestion
+	if code := requestWithHeaders(t, http.MethodPost, "/jobs", headers, jobRequest{Jobs: docs}, &ingestion); code != http.StatusAccepted {
+		t.Fatalf("POST /jobs code = %d, want %d", code, http.StatusAccepted)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if requestWithHeaders(t, http.MethodGet, "/ingestions/"+ingestion.ID, headers, nil, &ingestion); ingestion.Status == jobs.IngestionDone {
+			return
+		}
+	}
+	t.Fatalf("GET /ingestions/%s status = %s, want %s", ingestion.ID, ingestion.Status, jobs.IngestionDone)
+}
+
+func TestJobsAPI(t *testing.T) {
+	java := jobs.Job{Title: "Analista de Sistemas Java", Description: "Desenvolvimento em Java e Spring", Salary: 5000, City: []string{"Joinville"}, CityFormatted: []string{"Joinville - SC (2)"}}
+	golang := jobs.Job{Title: "Desenvolvedor Golang", Description: "Desenvolvimento em Go e Java", Salary: 7000, City: []string{"São Paulo"}, CityFormatted: []string{"São Paulo - SP (1)"}}
+	sales := jobs.Job{Title: "Vendedor", Description: "Vendas no varejo", Salary: 1500, City: []string{"Curitiba"}, CityFormatted: []string{"Curitiba - PR"}}
+	addJobs(t, java, golang, sales)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantIDs  []string
+	}{
+		{"content", "/jobs?content=java", http.StatusOK, []string{golang.ID(), java.ID()}},
+		{"content without accents", "/jobs?city=sao%20paulo", http.StatusOK, []string{golang.ID()}},
+		{"salary sort", "/jobs?q=java%20OR%20vendas&sort=asc", http.StatusOK, []string{sales.ID(), java.ID(), golang.ID()}},
+		{"query language", "/jobs?q=java%20-title:golang", http.StatusOK, []string{java.ID()}},
+		{"state", "/jobs?state=sc", http.StatusOK, []string{java.ID()}},
+		{"fuzzy", "/jobs?content=vendedr&fuzzy=auto", http.StatusOK, []string{sales.ID()}},
+		{"similar", "/jobs/" + java.ID() + "/similar", http.StatusOK, []string{golang.ID()}},
+		{"missing criteria", "/jobs", http.StatusBadRequest, nil},
+		{"invalid param", "/jobs?page=abc", http.StatusBadRequest, nil},
+		{"page zero", "/jobs?content=java&page=0", http.StatusBadRequest, nil},
+		{"size zero", "/jobs?content=java&size=0", http.StatusBadRequest, nil},
+		{"not a number salary", "/jobs?content=java&salary_min=NaN", http.StatusBadRequest, nil},
+		{"infinite salary", "/jobs?content=java&salary_max=Inf", http.StatusBadRequest, nil},
+		{"invalid query", "/jobs?q=java%20AND", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result jobs.JobSearchResult
+			if code := request(t, http.MethodGet, tt.path, nil, &result); code != tt.wantCode {
+				t.Errorf("GET %s code = %d, want %d", tt.path, code, tt.wantCode)
+				return
+			}
+			if tt.wantIDs == nil {
+				return
+			}
+			ids := []string{}
+			for _, job := range result.Jobs {
+				ids = append(ids, job.ID)
+			}
+			if len(ids) != len(tt.wantIDs) || (len(ids) > 0 && ids[0] != tt.wantIDs[0]) {
+				t.Errorf("GET %s = %v, want %v", tt.path, ids, tt.wantIDs)
+			}
+		})
+	}
+
+	var facets jobs.JobSearchResult
+	request(t, http.MethodGet, "/jobs?q=java%20OR%20vendas&facets=state,salary&salary_buckets=2000,6000", nil, &facets)
+	if got := facets.Facets["salary"]; len(got) != 3 || got[0].Count != 1 || got[1].Count != 1 || got[2].Count != 1 {
+		t.Errorf("GET /jobs salary facet = %+v, want one job on each bucket", got)
+	}
+
+	var suggestions suggestResponse
+	if code := request(t, http.MethodGet, "/jobs/_suggest?field=city&prefix=sao", nil, &suggestions); code != http.StatusOK || len(suggestions.Suggestions) != 1 || suggestions.Suggestions[0].Text != "São Paulo" {
+		t.Errorf("GET /jobs/_suggest = %d %+v, want São Paulo", code, suggestions)
+	}
+
+	var hit jobs.JobHit
+	if code := request(t, http.MethodGet, "/jobs/"+java.ID(), nil, &hit); code != http.StatusOK || hit.Title != java.Title {
+		t.Errorf("GET /jobs/%s = %d %+v, want %s", java.ID(), code, hit, java.Title)
+	}
+	if code := request(t, http.MethodDelete, "/jobs/"+java.ID(), nil, nil); code != http.StatusNoContent {
+		t.Errorf("DELETE /jobs/%s code = %d, want %d", java.ID(), code, http.StatusNoContent)
+	}
+	var jobErr jobs.JobError
+	if code := request(t, http.MethodGet, "/jobs/"+java.ID(), nil, &jobErr); code != http.StatusNotFound || jobErr.ErrCode != jobs.JOB1002 {
+		t.Errorf("GET /jobs/%s = %d %+v, want not found", java.ID(), code, jobErr)
+	}
+	if code := request(t, http.MethodGet, "/unknown", nil, &jobErr); code != http.StatusNotFound {
+		t.Errorf("GET /unknown code = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestSavedSearchesAPI(t *testing.T) {
+	addJobs(t, jobs.Job{Title: "Desenvolvedor Python", Description: "Django", Salary: 6000, City: []string{"Florianópolis"}})
+
+	var search jobs.SavedSearch
+	if code := request(t, http.MethodPost, "/searches", jobs.SavedSearch{Name: "python", Query: "title:python"}, &search); code != http.StatusCreated || search.ID == "" {
+		t.Fatalf("POST /searches = %d %+v, want created", code, search)
+	}
+	if code := request(t, http.MethodPost, "/searches", jobs.SavedSearch{Name: "invalid", Query: "title:(python"}, nil); code != http.StatusBadRequest {
+		t.Errorf("POST /searches invalid query code = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	var list jobs.SavedSearchList
+	if code := request(t, http.MethodGet, "/searches", nil, &list); code != http.StatusOK || list.Total != 1 || list.Searches[0].ID != search.ID {
+		t.Errorf("GET /searches = %d %+v, want %s", code, list, search.ID)
+	}
+
+	var result jobs.JobSearchResult
+	if code := request(t, http.MethodGet, "/searches/"+search.ID+"/results", nil, &result); code != http.StatusOK || result.Total != 1 || result.Jobs[0].Title != "Desenvolvedor Python" {
+		t.Errorf("GET /searches/%s/results = %d %+v, want python job", search.ID, code, result)
+	}
+
+	if code := request(t, http.MethodDelete, "/searches/"+search.ID, nil, nil); code != http.StatusNoContent {
+		t.Errorf("DELETE /searches/%s code = %d, want %d", search.ID, code, http.StatusNoContent)
+	}
+	if code := request(t, http.MethodGet, "/searches/"+search.ID+"/results", nil, nil); code != http.StatusNotFound {
+		t.Errorf("GET /searches/%s/results code = %d, want %d", search.ID, code, http.StatusNotFound)
+	}
+}
+
+// bearerToken HS256 token signed with testJWTSecret for subject with scope
+func bearerToken(subject, scope string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{"sub": subject, "iss": "gateway", "aud": "c-jobs", "exp": time.Now().Add(time.Minute).Unix(), "scope": scope})
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signed))
+	return "Bearer " + signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthAPI(t *testing.T) {
+	job := jobs.Job{Title: "Analista de Suporte", Description: "Suporte técnico", Salary: 3000, City: []string{"Recife"}}
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		auth     string
+		body     interface{}
+		wantCode int
+	}{
+		{"missing key", http.MethodPost, "/jobs", "", jobRequest{Jobs: []jobs.Job{job}}, http.StatusUnauthorized},
+		{"invalid key", http.MethodPost, "/jobs", "invalid", jobRequest{Jobs: []jobs.Job{job}}, http.StatusUnauthorized},
+		{"missing delete scope", http.MethodDelete, "/jobs/" + job.ID(), writerAPIKey, nil, http.StatusForbidden},
+		{"missing delete scope on bulk delete", http.MethodPost, "/jobs/_delete", writerAPIKey, jobRequest{Jobs: []jobs.Job{job}}, http.StatusForbidden},
+		{"missing admin scope", http.MethodPost, "/admin/synonyms/_reload", testAPIKey, nil, http.StatusForbidden},
+		{"write scope", http.MethodPost, "/jobs", writerAPIKey, jobRequest{Jobs: []jobs.Job{job}}, http.StatusAccepted},
+		{"read without key", http.MethodGet, "/jobs?content=java", "", nil, http.StatusOK},
+		{"bearer token", http.MethodPost, "/jobs", bearerToken("ingester", "jobs:write"), jobRequest{Jobs: []jobs.Job{job}}, http.StatusAccepted},
+		{"bearer token without scope", http.MethodDelete, "/jobs/" + job.ID(), bearerToken("ingester", "jobs:write"), nil, http.StatusForbidden},
+		{"invalid bearer token", http.MethodPost, "/jobs", bearerToken("ingester", "jobs:write") + "x", jobRequest{Jobs: []jobs.Job{job}}, http.StatusUnauthorized},
+		{"basic authorization", http.MethodPost, "/jobs", "Basic dXNlcjpwYXNz", jobRequest{Jobs: []jobs.Job{job}}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := apiKeyHeader
+			if strings.Contains(tt.auth, " ") {
+				header = authorizationHeader
+			}
+			var jobErr jobs.JobError
+			code := requestWithHeaders(t, tt.method, tt.path, map[string]string{header: tt.auth}, tt.body, &jobErr)
+			if code != tt.wantCode {
+				t.Errorf("%s %s code = %d, want %d", tt.method, tt.path, code, tt.wantCode)
+			}
+			if code >= http.StatusBadRequest && jobErr.ErrCode != jobs.JOB1004 {
+				t.Errorf("%s %s error = %+v, want %s", tt.method, tt.path, jobErr, jobs.JOB1004)
+			}
+		})
+	}
+}
+
+func TestTenantsAPI(t *testing.T) {
+	acme := jobs.Job{Title: "Engenheiro de Dados", Description: "Spark e Scala", Salary: 9000, City: []string{"Recife"}}
+	globex := jobs.Job{Title: "Engenheiro Civil", Description: "Obras", Salary: 8000, City: []string{"Recife"}}
+	addTenantJobs(t, map[string]string{apiKeyHeader: acmeAPIKey, tenantHeader: "acme"}, acme)
+	addTenantJobs(t, map[string]string{apiKeyHeader: testAPIKey, tenantHeader: "globex"}, globex)
+
+	tests := []struct {
+		name     string
+		tenant   string
+		apiKey   string
+		wantCode int
+		wantIDs  []string
+	}{
+		{"acme", "acme", acmeAPIKey, http.StatusOK, []string{acme.ID()}},
+		{"globex with key without tenant", "globex", testAPIKey, http.StatusOK, []string{globex.ID()}},
+		{"default tenant", "", "", http.StatusOK, []string{}},
+		{"tenant without credential", "acme", "", http.StatusUnauthorized, nil},
+		{"tenant with invalid key", "acme", "invalid", http.StatusUnauthorized, nil},
+		{"credential of other tenant", "globex", acmeAPIKey, http.StatusForbidden, nil},
+		{"unknown tenant", "initech", testAPIKey, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result jobs.JobSearchResult
+			if code := requestWithHeaders(t, http.MethodGet, "/jobs?content=engenheiro", map[string]string{tenantHeader: tt.tenant, apiKeyHeader: tt.apiKey}, nil, &result); code != tt.wantCode {
+				t.Errorf("GET /jobs tenant %s code = %d, want %d", tt.tenant, code, tt.wantCode)
+				return
+			}
+			if tt.wantIDs == nil {
+				return
+			}
+			ids := []string{}
+			for _, job := range result.Jobs {
+				ids = append(ids, job.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("GET /jobs tenant %s = %v, want %v", tt.tenant, ids, tt.wantIDs)
+			}
+		})
+	}
+
+	var jobErr jobs.JobError
+	if code := requestWithHeaders(t, http.MethodPost, "/jobs", map[string]string{apiKeyHeader: acmeAPIKey, tenantHeader: "globex"}, jobRequest{Jobs: []jobs.Job{acme}}, &jobErr); code != http.StatusForbidden || jobErr.ErrCode != jobs.JOB1004 {
+		t.Errorf("POST /jobs with credential of other tenant = %d %+v, want forbidden", code, jobErr)
+	}
+	for _, path := range []string{"/searches", "/ingestions/unknown"} {
+		if code := requestWithHeaders(t, http.MethodGet, path, map[string]string{tenantHeader: "acme"}, nil, nil); code != http.StatusUnauthorized {
+			t.Errorf("GET %s of tenant without credential code = %d, want %d", path, code, http.StatusUnauthorized)
+		}
+	}
+}